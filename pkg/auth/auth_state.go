@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+// DefaultAuthStateTTL is how long an auth URL waits for its callback
+const DefaultAuthStateTTL = 10 * time.Minute
+
+// MemoryAuthStateStore keeps pending authorization requests in process memory
+type MemoryAuthStateStore struct {
+	mu      sync.Mutex
+	entries map[string]*types.AuthState
+}
+
+// NewMemoryAuthStateStore creates an empty MemoryAuthStateStore
+func NewMemoryAuthStateStore() *MemoryAuthStateStore {
+	return &MemoryAuthStateStore{entries: map[string]*types.AuthState{}}
+}
+
+func (s *MemoryAuthStateStore) Put(ctx context.Context, state string, data *types.AuthState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// callbacks that never come would otherwise pile up
+	for key, entry := range s.entries {
+		if !now.Before(entry.ExpiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	entry := *data
+	entry.ExpiresAt = now.Add(ttl)
+	s.entries[state] = &entry
+	return nil
+}
+
+func (s *MemoryAuthStateStore) Take(ctx context.Context, state string) (*types.AuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.entries, state)
+	if !time.Now().Before(entry.ExpiresAt) {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// KVAuthStateStore adapts a KeyValueCache into an AuthStateStore, relying on
+// the cache to expire entries. Take is a get followed by a delete, so two
+// concurrent callbacks with the same state may both succeed.
+type KVAuthStateStore struct {
+	cache  KeyValueCache
+	prefix string
+}
+
+// NewKVAuthStateStore stores pending requests in cache under keys starting with prefix
+func NewKVAuthStateStore(cache KeyValueCache, prefix string) *KVAuthStateStore {
+	return &KVAuthStateStore{cache: cache, prefix: prefix}
+}
+
+func (s *KVAuthStateStore) Put(ctx context.Context, state string, data *types.AuthState, ttl time.Duration) error {
+	entry := *data
+	entry.ExpiresAt = time.Now().Add(ttl)
+	encoded, err := json.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, s.prefix+state, encoded, ttl)
+}
+
+func (s *KVAuthStateStore) Take(ctx context.Context, state string) (*types.AuthState, error) {
+	data, err := s.cache.Get(ctx, s.prefix+state)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	if err := s.cache.Del(ctx, s.prefix+state); err != nil {
+		return nil, err
+	}
+	var entry types.AuthState
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("[GlideClient] Failed to parse stored auth state: %w", err)
+	}
+	if !time.Now().Before(entry.ExpiresAt) {
+		return nil, nil
+	}
+	return &entry, nil
+}
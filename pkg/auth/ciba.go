@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+// Token endpoint errors of a CIBA poll, see OpenID CIBA Core section 11
+const (
+	ErrorAuthorizationPending = "authorization_pending"
+	ErrorSlowDown             = "slow_down"
+	ErrorAccessDenied         = "access_denied"
+	ErrorExpiredToken         = "expired_token"
+)
+
+const (
+	// DefaultPollInterval is the poll interval when the server does not set one
+	DefaultPollInterval = 5 * time.Second
+	// SlowDownIncrement is added to the poll interval on every slow_down
+	SlowDownIncrement = 5 * time.Second
+)
+
+// BackchannelResponse is the body returned by the backchannel authentication endpoint
+type BackchannelResponse struct {
+	AuthReqID string `json:"auth_req_id"`
+	// ExpiresIn is the lifetime of the request in seconds
+	ExpiresIn int64 `json:"expires_in"`
+	// Interval is the minimum number of seconds between polls, 0 if unset
+	Interval int64 `json:"interval"`
+	// ConsentURL is where the user approves the request, when the operator asks for explicit consent
+	ConsentURL string `json:"consentUrl"`
+}
+
+// PollInterval is the interval asked for by the server or DefaultPollInterval
+func (r *BackchannelResponse) PollInterval() time.Duration {
+	if r.Interval <= 0 {
+		return DefaultPollInterval
+	}
+	return time.Duration(r.Interval) * time.Second
+}
+
+// ExpiresAt is when the request expires relative to now, or the zero time if the server did not say
+func (r *BackchannelResponse) ExpiresAt() time.Time {
+	if r.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+}
+
+// Notification is the body of a CIBA ping or push callback. A ping only carries
+// the AuthReqID; a push also carries the token, or the error if the user did
+// not approve.
+type Notification struct {
+	AuthReqID        string `json:"auth_req_id"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Session converts a successful push into a session, or returns nil for a ping or failure
+func (n *Notification) Session() *types.Session {
+	if n.AccessToken == "" {
+		return nil
+	}
+	return (&TokenResponse{AccessToken: n.AccessToken, ExpiresIn: n.ExpiresIn, Scope: n.Scope}).Session()
+}
+
+// NewNotificationToken returns a random client_notification_token
+func NewNotificationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NotificationWaiter is a pending CIBA request waiting for its callback
+type NotificationWaiter struct {
+	done chan struct{}
+
+	mu           sync.Mutex
+	authReqID    string
+	notification *Notification
+}
+
+// SetAuthReqID makes the waiter accept only callbacks for authReqID
+func (w *NotificationWaiter) SetAuthReqID(authReqID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.authReqID = authReqID
+}
+
+// Done is closed when the callback arrived
+func (w *NotificationWaiter) Done() <-chan struct{} {
+	return w.done
+}
+
+// Notification is the callback received, or nil until Done is closed
+func (w *NotificationWaiter) Notification() *Notification {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.notification
+}
+
+// deliver hands n to the waiter, reporting false if it is for another request
+func (w *NotificationWaiter) deliver(n *Notification) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.authReqID != "" && n.AuthReqID != w.authReqID {
+		return false
+	}
+	if w.notification == nil {
+		w.notification = n
+		close(w.done)
+	}
+	return true
+}
+
+// NotificationHandler receives CIBA ping and push callbacks on the client
+// notification endpoint registered with the authorization server. Each callback
+// is authenticated by the client_notification_token of its request, sent as a
+// bearer token, and handed to the waiter registered under it.
+type NotificationHandler struct {
+	mu      sync.Mutex
+	waiters map[string]*NotificationWaiter
+}
+
+// NewNotificationHandler creates a NotificationHandler without waiters
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{waiters: map[string]*NotificationWaiter{}}
+}
+
+// Register creates the waiter for the request sent with token
+func (h *NotificationHandler) Register(token string) *NotificationWaiter {
+	w := &NotificationWaiter{done: make(chan struct{})}
+	h.mu.Lock()
+	h.waiters[token] = w
+	h.mu.Unlock()
+	return w
+}
+
+// Unregister drops the waiter of token, e.g. when its request expired
+func (h *NotificationHandler) Unregister(token string) {
+	h.mu.Lock()
+	delete(h.waiters, token)
+	h.mu.Unlock()
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	h.mu.Lock()
+	waiter := h.waiters[token]
+	h.mu.Unlock()
+	if !ok || waiter == nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var n Notification
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&n); err != nil || n.AuthReqID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !waiter.deliver(&n) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h.Unregister(token)
+	w.WriteHeader(http.StatusNoContent)
+}
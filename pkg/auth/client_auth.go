@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// ClientAssertionType is the client_assertion_type of private_key_jwt
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ClientAssertionLifetime is how long a private_key_jwt assertion is valid
+const ClientAssertionLifetime = time.Minute
+
+// ValidateClientAuth checks that settings carry what their client
+// authentication method needs
+func ValidateClientAuth(settings types.GlideSdkSettings) error {
+	if settings.ClientID == "" {
+		return &utils.ValidationError{Field: "ClientID", Message: "Client credentials are required to generate a new session"}
+	}
+	switch settings.ClientAuth.Method {
+	case "", types.ClientSecretBasic:
+		if settings.ClientSecret == "" {
+			return &utils.ValidationError{Field: "ClientSecret", Message: "Client credentials are required to generate a new session"}
+		}
+	case types.PrivateKeyJWT:
+		if settings.ClientAuth.SigningKey == nil {
+			return &utils.ValidationError{Field: "ClientAuth.SigningKey", Message: "A signing key is required for private_key_jwt"}
+		}
+		if _, _, err := assertionAlgorithm(settings.ClientAuth.SigningKey); err != nil {
+			return &utils.ValidationError{Field: "ClientAuth.SigningKey", Message: "private_key_jwt needs an RSA or EC (P-256, P-384, P-521) signing key"}
+		}
+	case types.TLSClientAuth:
+		if settings.HTTPClient != nil {
+			break
+		}
+		if settings.ClientAuth.Certificate == nil {
+			return &utils.ValidationError{Field: "ClientAuth.Certificate", Message: "A client certificate is required for tls_client_auth"}
+		}
+		if _, ok := settings.Transport.(*http.Transport); settings.Transport != nil && !ok {
+			return &utils.ValidationError{Field: "Transport", Message: "tls_client_auth needs an *http.Transport or an HTTPClient set up with the certificate"}
+		}
+	default:
+		return &utils.ValidationError{Field: "ClientAuth.Method", Message: fmt.Sprintf("unknown client authentication method %q", settings.ClientAuth.Method)}
+	}
+	return nil
+}
+
+// AuthenticateClient adds the client authentication of settings to a request:
+// the Authorization header for client_secret_basic, a client assertion in form
+// for private_key_jwt, or only client_id for tls_client_auth, whose certificate
+// goes with the connection. The assertion audience comes from discovery; nil
+// discovers it anew.
+func AuthenticateClient(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, headers map[string]string, form url.Values) error {
+	if err := ValidateClientAuth(settings); err != nil {
+		return err
+	}
+	switch settings.ClientAuth.Method {
+	case types.PrivateKeyJWT:
+		assertion, err := clientAssertion(ctx, settings, discoveryOrNew(settings, discovery))
+		if err != nil {
+			return utils.NewError("auth.client-assertion", nil, "Failed to sign client assertion", err)
+		}
+		form.Set("client_id", settings.ClientID)
+		form.Set("client_assertion_type", ClientAssertionType)
+		form.Set("client_assertion", assertion)
+	case types.TLSClientAuth:
+		form.Set("client_id", settings.ClientID)
+	default:
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(settings.ClientID+":"+settings.ClientSecret))
+	}
+	return nil
+}
+
+// clientAssertion signs a single use JWT identifying the client to the
+// authorization server, whose issuer is the audience
+func clientAssertion(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery) (string, error) {
+	audience := discovery.Endpoints(ctx).Token
+	if metadata, err := discovery.Metadata(ctx); err == nil && metadata.Issuer != "" {
+		audience = metadata.Issuer
+	}
+	key := settings.ClientAuth.SigningKey
+	alg, hash, err := assertionAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if settings.ClientAuth.KeyID != "" {
+		header["kid"] = settings.ClientAuth.KeyID
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss": settings.ClientID,
+		"sub": settings.ClientID,
+		"aud": audience,
+		"jti": uuid.New().String(),
+		"iat": now.Unix(),
+		"exp": now.Add(ClientAssertionLifetime).Unix(),
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	h := hash.New()
+	h.Write([]byte(signed))
+	signature, err := key.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return "", err
+	}
+	if ecKey, ok := key.Public().(*ecdsa.PublicKey); ok {
+		if signature, err = rawECDSASignature(signature, ecKey); err != nil {
+			return "", err
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// assertionAlgorithm picks the JWS algorithm by the public key, so signers
+// backed by an HSM or KMS work as well as in-memory keys
+func assertionAlgorithm(key crypto.Signer) (string, crypto.Hash, error) {
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		return "RS256", crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve.Params().BitSize {
+		case 256:
+			return "ES256", crypto.SHA256, nil
+		case 384:
+			return "ES384", crypto.SHA384, nil
+		case 521:
+			return "ES512", crypto.SHA512, nil
+		}
+	}
+	return "", 0, fmt.Errorf("[GlideClient] Unsupported signing key %T", key)
+}
+
+// rawECDSASignature converts the ASN.1 signature of crypto.Signer into the
+// fixed size r and s JWS expects
+func rawECDSASignature(der []byte, key *ecdsa.PublicKey) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	size := (key.Curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+)
+
+// IDTokenLeeway absorbs clock skew when checking exp and iat
+const IDTokenLeeway = time.Minute
+
+var signatureHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// IDTokenVerifier checks id_tokens against the issuer and signing keys
+// published in the OpenID configuration of the authorization server
+type IDTokenVerifier struct {
+	settings  types.GlideSdkSettings
+	discovery *Discovery
+
+	mu   sync.Mutex
+	keys *KeySet
+}
+
+// NewIDTokenVerifier creates an IDTokenVerifier for tokens issued to the client
+// of settings; a nil discovery gives the verifier one of its own
+func NewIDTokenVerifier(settings types.GlideSdkSettings, discovery *Discovery) *IDTokenVerifier {
+	return &IDTokenVerifier{settings: settings, discovery: discoveryOrNew(settings, discovery)}
+}
+
+// Verify checks the signature, issuer, audience and expiry of rawIDToken and,
+// unless nonce is empty, that it was issued for nonce
+func (v *IDTokenVerifier) Verify(ctx context.Context, rawIDToken, nonce string) (*types.IDTokenClaims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, invalidIDToken("id_token is not a signed JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidIDToken("id_token header is malformed")
+	}
+	hash, ok := signatureHashes[header.Alg]
+	if !ok {
+		return nil, invalidIDToken(fmt.Sprintf("id_token algorithm %q is not allowed", header.Alg))
+	}
+
+	metadata, err := v.discovery.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, err := v.keySet(metadata.JWKSURI).Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.Alg != "" && key.Alg != header.Alg {
+		return nil, invalidIDToken(fmt.Sprintf("key %q does not sign %s", key.KeyID, header.Alg))
+	}
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return nil, utils.NewError("auth.id-token", utils.ErrInvalidToken, "Unusable signing key", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !verifySignature(publicKey, header.Alg, hash, parts[0]+"."+parts[1], signature) {
+		return nil, invalidIDToken("id_token signature is invalid")
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, invalidIDToken("id_token claims are malformed")
+	}
+	claims := claimsFrom(raw)
+	now := time.Now()
+	switch {
+	case claims.Issuer != metadata.Issuer:
+		return nil, invalidIDToken(fmt.Sprintf("id_token issuer %q is not %q", claims.Issuer, metadata.Issuer))
+	case !contains(claims.Audience, v.settings.ClientID):
+		return nil, invalidIDToken("id_token was issued to another audience")
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != v.settings.ClientID:
+		return nil, invalidIDToken("id_token was authorized for another party")
+	case claims.ExpiresAt.IsZero() || now.After(claims.ExpiresAt.Add(IDTokenLeeway)):
+		return nil, invalidIDToken("id_token expired")
+	case claims.IssuedAt.After(now.Add(IDTokenLeeway)):
+		return nil, invalidIDToken("id_token was issued in the future")
+	case nonce != "" && claims.Nonce != nonce:
+		return nil, invalidIDToken("id_token nonce does not match the auth URL")
+	}
+	return claims, nil
+}
+
+// keySet returns the cached keys of jwksURI, replacing them if the URI changed
+func (v *IDTokenVerifier) keySet(jwksURI string) *KeySet {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.keys == nil || v.keys.url != jwksURI {
+		v.keys = NewKeySet(v.settings, jwksURI)
+	}
+	return v.keys
+}
+
+func verifySignature(publicKey crypto.PublicKey, alg string, hash crypto.Hash, signed string, signature []byte) bool {
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// JWS carries r and s as fixed size big-endian integers
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func claimsFrom(raw map[string]interface{}) *types.IDTokenClaims {
+	claims := &types.IDTokenClaims{Raw: raw}
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Nonce, _ = raw["nonce"].(string)
+	claims.AuthorizedParty, _ = raw["azp"].(string)
+	switch aud := raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				claims.Audience = append(claims.Audience, s)
+			}
+		}
+	}
+	if exp, ok := raw["exp"].(float64); ok {
+		claims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	if iat, ok := raw["iat"].(float64); ok {
+		claims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	return claims
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidIDToken(message string) error {
+	return utils.NewError("auth.id-token", utils.ErrInvalidToken, message, nil)
+}
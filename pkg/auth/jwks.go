@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+)
+
+// KeySetRefreshInterval limits how often unknown key IDs trigger a refetch, so
+// tokens with made up key IDs cannot flood the JWKS endpoint
+const KeySetRefreshInterval = time.Minute
+
+// JSONWebKey is a public key of a JWKS document
+type JSONWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// PublicKey decodes the RSA or EC public key
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("[GlideClient] Invalid RSA modulus of key %q: %w", k.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("[GlideClient] Invalid RSA exponent of key %q", k.KeyID)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("[GlideClient] Unsupported curve %q of key %q", k.Curve, k.KeyID)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("[GlideClient] Invalid coordinates of key %q", k.KeyID)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("[GlideClient] Key %q is not on curve %s", k.KeyID, k.Curve)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("[GlideClient] Unsupported key type %q of key %q", k.KeyType, k.KeyID)
+}
+
+// KeySet caches the signing keys published at a JWKS URI. Keys are refetched
+// after DiscoveryTTL, or sooner when a token names a key ID the cache does not
+// know, which is how key rotation shows up.
+type KeySet struct {
+	settings types.GlideSdkSettings
+	url      string
+
+	mu        sync.Mutex
+	keys      []JSONWebKey
+	fetchedAt time.Time
+	// lastMiss is when an unknown key ID last caused a refetch
+	lastMiss time.Time
+}
+
+// NewKeySet creates a KeySet for the JWKS document at url
+func NewKeySet(settings types.GlideSdkSettings, url string) *KeySet {
+	return &KeySet{settings: settings, url: url}
+}
+
+// Key returns the signing key with kid, or the only signing key if kid is empty
+func (s *KeySet) Key(ctx context.Context, kid string) (*JSONWebKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.fetchedAt) < DiscoveryTTL {
+		if key := s.find(kid); key != nil {
+			return key, nil
+		}
+		if time.Since(s.lastMiss) < KeySetRefreshInterval {
+			return nil, utils.NewError("auth.jwks", utils.ErrInvalidToken, fmt.Sprintf("Unknown signing key %q", kid), nil)
+		}
+		s.lastMiss = time.Now()
+	}
+	var doc struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, s.settings, s.url, &doc); err != nil {
+		return nil, utils.WrapError("auth.jwks", "Failed to fetch signing keys", err)
+	}
+	s.keys, s.fetchedAt = doc.Keys, time.Now()
+	if key := s.find(kid); key != nil {
+		return key, nil
+	}
+	return nil, utils.NewError("auth.jwks", utils.ErrInvalidToken, fmt.Sprintf("Unknown signing key %q", kid), nil)
+}
+
+func (s *KeySet) find(kid string) *JSONWebKey {
+	var match *JSONWebKey
+	for i := range s.keys {
+		key := &s.keys[i]
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.KeyID == kid {
+			return key
+		}
+		if kid == "" {
+			if match != nil {
+				// ambiguous without a key ID
+				return nil
+			}
+			match = key
+		}
+	}
+	return match
+}
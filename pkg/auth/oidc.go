@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+)
+
+// DiscoveryTTL is how long provider metadata and signing keys are cached
+const DiscoveryTTL = time.Hour
+
+// DiscoveryRetryInterval is how long a failed discovery is not attempted again,
+// during which Endpoints falls back to the default paths
+const DiscoveryRetryInterval = time.Minute
+
+// Endpoint paths under AuthBaseURL used when the OpenID configuration is
+// unavailable or leaves an endpoint out
+const (
+	DefaultAuthorizationPath = "/oauth2/auth"
+	DefaultTokenPath         = "/oauth2/token"
+	DefaultBackchannelPath   = "/oauth2/backchannel-authentication"
+	DefaultRevocationPath    = "/oauth2/revoke"
+	DefaultIntrospectionPath = "/oauth2/introspect"
+)
+
+// ProviderMetadata is the subset of the OpenID provider configuration the SDK uses
+type ProviderMetadata struct {
+	Issuer                            string `json:"issuer"`
+	AuthorizationEndpoint             string `json:"authorization_endpoint"`
+	TokenEndpoint                     string `json:"token_endpoint"`
+	BackchannelAuthenticationEndpoint string `json:"backchannel_authentication_endpoint"`
+	JWKSURI                           string `json:"jwks_uri"`
+	RevocationEndpoint                string `json:"revocation_endpoint"`
+	IntrospectionEndpoint             string `json:"introspection_endpoint"`
+}
+
+// Endpoints are the URLs the SDK sends authorization requests to
+type Endpoints struct {
+	Authorization             string
+	Token                     string
+	BackchannelAuthentication string
+	Revocation                string
+	Introspection             string
+}
+
+// Discovery fetches and caches the OpenID configuration published under
+// AuthBaseURL through the HTTP client of its settings. It is safe for
+// concurrent use; the clients created together by a GlideClient share one.
+type Discovery struct {
+	settings types.GlideSdkSettings
+
+	mu        sync.Mutex
+	metadata  *ProviderMetadata
+	err       error
+	fetchedAt time.Time
+}
+
+// NewDiscovery creates a Discovery for the authorization server of settings
+func NewDiscovery(settings types.GlideSdkSettings) *Discovery {
+	return &Discovery{settings: settings}
+}
+
+// discoveryOrNew returns d, or a Discovery for settings alone when d is nil
+func discoveryOrNew(settings types.GlideSdkSettings, d *Discovery) *Discovery {
+	if d == nil {
+		return NewDiscovery(settings)
+	}
+	return d
+}
+
+// Metadata returns the provider configuration, fetching it when the cached copy
+// is older than DiscoveryTTL
+func (d *Discovery) Metadata(ctx context.Context) (*ProviderMetadata, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.metadata != nil && time.Since(d.fetchedAt) < DiscoveryTTL {
+		return d.metadata, nil
+	}
+	if d.err != nil && time.Since(d.fetchedAt) < DiscoveryRetryInterval {
+		return nil, d.err
+	}
+	var metadata ProviderMetadata
+	if err := getJSON(ctx, d.settings, d.settings.Internal.AuthBaseURL+"/.well-known/openid-configuration", &metadata); err != nil {
+		err = utils.WrapError("auth.discovery", "Failed to fetch OpenID configuration", err)
+		// the caller giving up says nothing about the server
+		if ctx.Err() == nil {
+			d.err, d.fetchedAt = err, time.Now()
+		}
+		return nil, err
+	}
+	d.metadata, d.err, d.fetchedAt = &metadata, nil, time.Now()
+	return d.metadata, nil
+}
+
+// Endpoints resolves the authorization endpoints from the provider
+// configuration, falling back to the default paths under AuthBaseURL
+func (d *Discovery) Endpoints(ctx context.Context) Endpoints {
+	base := d.settings.Internal.AuthBaseURL
+	endpoints := Endpoints{
+		Authorization:             base + DefaultAuthorizationPath,
+		Token:                     base + DefaultTokenPath,
+		BackchannelAuthentication: base + DefaultBackchannelPath,
+		Revocation:                base + DefaultRevocationPath,
+		Introspection:             base + DefaultIntrospectionPath,
+	}
+	metadata, err := d.Metadata(ctx)
+	if err != nil {
+		utils.Logger(d.settings).DebugContext(ctx, "using default endpoints, discovery failed", "error", err)
+		return endpoints
+	}
+	if metadata.AuthorizationEndpoint != "" {
+		endpoints.Authorization = metadata.AuthorizationEndpoint
+	}
+	if metadata.TokenEndpoint != "" {
+		endpoints.Token = metadata.TokenEndpoint
+	}
+	if metadata.BackchannelAuthenticationEndpoint != "" {
+		endpoints.BackchannelAuthentication = metadata.BackchannelAuthenticationEndpoint
+	}
+	if metadata.RevocationEndpoint != "" {
+		endpoints.Revocation = metadata.RevocationEndpoint
+	}
+	if metadata.IntrospectionEndpoint != "" {
+		endpoints.Introspection = metadata.IntrospectionEndpoint
+	}
+	return endpoints
+}
+
+// getJSON fetches url through the HTTP client of settings and decodes the body into v
+func getJSON(ctx context.Context, settings types.GlideSdkSettings, url string, v interface{}) error {
+	resp, err := utils.FetchXWithContext(ctx, url, utils.FetchXInput{
+		Method:     "GET",
+		Headers:    map[string]string{"Accept": "application/json"},
+		Client:     utils.HTTPClient(settings),
+		Retry:      settings.RetryPolicy,
+		Logger:     utils.Logger(settings),
+		Redactor:   utils.RedactorFor(settings),
+		Tracer:     utils.Tracer(settings),
+		Idempotent: true,
+	})
+	if err != nil {
+		return err
+	}
+	return resp.JSON(v)
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// CodeChallengeS256 is the only PKCE challenge method the SDK sends
+const CodeChallengeS256 = "S256"
+
+// PKCE is a proof key for an authorization code exchange (RFC 7636). The
+// challenge goes in the authorization URL, the verifier in the code exchange.
+type PKCE struct {
+	Verifier  string
+	Challenge string
+	Method    string
+}
+
+// NewPKCE generates a random verifier and its S256 challenge
+func NewPKCE() (*PKCE, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	return &PKCE{Verifier: verifier, Challenge: S256Challenge(verifier), Method: CodeChallengeS256}, nil
+}
+
+// S256Challenge derives the S256 code challenge of verifier
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
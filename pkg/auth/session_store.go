@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+// MemorySessionStore keeps sessions in process memory
+type MemorySessionStore struct {
+	mu      sync.Mutex
+	entries map[string]storedSession
+	// sweepAt is the number of entries at which expired ones are dropped
+	sweepAt int
+}
+
+type storedSession struct {
+	Session   *types.Session `json:"session"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+}
+
+func (s storedSession) live(now time.Time) bool {
+	return s.Session != nil && now.Before(s.ExpiresAt)
+}
+
+// NewMemorySessionStore creates an empty MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{entries: map[string]storedSession{}, sweepAt: minSweep}
+}
+
+func (s *MemorySessionStore) Get(ctx context.Context, key string) (*types.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !entry.live(time.Now()) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+	return entry.Session, nil
+}
+
+func (s *MemorySessionStore) Put(ctx context.Context, key string, session *types.Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.entries[key] = storedSession{Session: session, ExpiresAt: now.Add(ttl)}
+	// sessions are kept per subscriber, so once the store doubled since the last
+	// sweep the expired ones are dropped rather than waiting for a Get
+	if len(s.entries) >= s.sweepAt {
+		for key, entry := range s.entries {
+			if !entry.live(now) {
+				delete(s.entries, key)
+			}
+		}
+		s.sweepAt = max(2*len(s.entries), minSweep)
+	}
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// FileSessionStore keeps one JSON file per key in a directory, so sessions
+// survive restarts and can be shared by processes on the same host
+type FileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore creates the directory if needed. Files hold access
+// tokens, so the directory and files are only readable by the current user.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("[GlideClient] Failed to create session store directory: %w", err)
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// path hashes key so it is always a safe file name
+func (s *FileSessionStore) path(key string) string {
+	return filepath.Join(s.dir, hashKey(key)+".json")
+}
+
+// hashKey hides the subscriber a session key names, e.g. a phone number or IP
+// address, from anyone listing the stored keys
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *FileSessionStore) Get(ctx context.Context, key string) (*types.Session, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry storedSession
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("[GlideClient] Failed to parse stored session: %w", err)
+	}
+	if !entry.live(time.Now()) {
+		return nil, s.Delete(ctx, key)
+	}
+	return entry.Session, nil
+}
+
+func (s *FileSessionStore) Put(ctx context.Context, key string, session *types.Session, ttl time.Duration) error {
+	data, err := json.Marshal(storedSession{Session: session, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+	// write then rename so concurrent readers never see a partial file
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+func (s *FileSessionStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// KeyValueCache is the subset of a Redis-compatible client the SDK needs. Get
+// must return a nil slice and no error for a missing key.
+type KeyValueCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// KVSessionStore adapts a KeyValueCache into a SessionStore, relying on the
+// cache to expire entries
+type KVSessionStore struct {
+	cache  KeyValueCache
+	prefix string
+}
+
+// NewKVSessionStore stores sessions in cache under keys starting with prefix,
+// followed by a hash of the session key
+func NewKVSessionStore(cache KeyValueCache, prefix string) *KVSessionStore {
+	return &KVSessionStore{cache: cache, prefix: prefix}
+}
+
+func (s *KVSessionStore) cacheKey(key string) string {
+	return s.prefix + hashKey(key)
+}
+
+func (s *KVSessionStore) Get(ctx context.Context, key string) (*types.Session, error) {
+	data, err := s.cache.Get(ctx, s.cacheKey(key))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var session types.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("[GlideClient] Failed to parse stored session: %w", err)
+	}
+	return &session, nil
+}
+
+func (s *KVSessionStore) Put(ctx context.Context, key string, session *types.Session, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, s.cacheKey(key), data, ttl)
+}
+
+func (s *KVSessionStore) Delete(ctx context.Context, key string) error {
+	return s.cache.Del(ctx, s.cacheKey(key))
+}
+
+// SessionTTL is how long session is worth storing: until it expires, or until
+// its refresh token does when that outlives it
+func SessionTTL(session *types.Session) time.Duration {
+	expiresAt := session.ExpiresAt
+	if session.RefreshExpiresAt > expiresAt {
+		expiresAt = session.RefreshExpiresAt
+	}
+	return time.Until(time.Unix(expiresAt, 0))
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+)
+
+// Grant types understood by the token endpoint
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantAuthorizationCode = "authorization_code"
+	GrantCIBA              = "urn:openid:params:grant-type:ciba"
+	GrantRefreshToken      = "refresh_token"
+)
+
+// DefaultRefreshTokenLifetime is assumed when the token endpoint does not say
+// how long a refresh token lasts
+const DefaultRefreshTokenLifetime = 30 * 24 * time.Hour
+
+// TokenResponse is the body returned by the token endpoint
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+	// IDToken is returned for the openid scope; check it with an IDTokenVerifier
+	IDToken          string `json:"id_token"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// Session converts the response into a session expiring relative to now
+func (r *TokenResponse) Session() *types.Session {
+	now := time.Now()
+	session := &types.Session{
+		AccessToken: r.AccessToken,
+		ExpiresAt:   now.Unix() + r.ExpiresIn,
+		Scopes:      strings.Split(r.Scope, " "),
+	}
+	if r.RefreshToken != "" {
+		lifetime := DefaultRefreshTokenLifetime
+		if r.RefreshExpiresIn > 0 {
+			lifetime = time.Duration(r.RefreshExpiresIn) * time.Second
+		}
+		session.RefreshToken = r.RefreshToken
+		session.RefreshExpiresAt = now.Add(lifetime).Unix()
+	}
+	return session
+}
+
+// RequestToken posts form to the token endpoint authenticated as the client of
+// settings. The endpoint is resolved through discovery; nil discovers it anew,
+// so clients making more than one request should keep a Discovery.
+func RequestToken(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, form url.Values) (*TokenResponse, error) {
+	discovery = discoveryOrNew(settings, discovery)
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	if err := AuthenticateClient(ctx, settings, discovery, headers, form); err != nil {
+		return nil, err
+	}
+
+	tokenURL := discovery.Endpoints(ctx).Token
+	resp, err := utils.FetchXWithContext(ctx, tokenURL, utils.FetchXInput{
+		Method:   "POST",
+		Headers:  headers,
+		Body:     form.Encode(),
+		Client:   utils.HTTPClient(settings),
+		Retry:    settings.RetryPolicy,
+		Logger:   utils.Logger(settings),
+		Redactor: utils.RedactorFor(settings),
+		Tracer:   utils.Tracer(settings),
+		// codes, auth requests and rotated refresh tokens are single use, only
+		// client credentials can be asked for again, and only without a client
+		// assertion, whose jti the server may refuse to see twice
+		Idempotent: form.Get("grant_type") == GrantClientCredentials && form.Get("client_assertion") == "",
+	})
+	if err != nil {
+		return nil, utils.WrapError("auth.token", "Failed to obtain token", err)
+	}
+
+	var body TokenResponse
+	if err := resp.JSON(&body); err != nil {
+		return nil, utils.NewError("auth.token", nil, "Failed to parse token response", err)
+	}
+	return &body, nil
+}
+
+// RefreshSession renews session with its refresh token. The refresh token is
+// kept if the server does not rotate it.
+func RefreshSession(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, session *types.Session) (*types.Session, error) {
+	if session == nil || session.RefreshToken == "" {
+		return nil, &utils.InsufficientSessionError{Message: "[GlideClient] Session expired and has no refresh token"}
+	}
+	body, err := RequestToken(ctx, settings, discovery, url.Values{
+		"grant_type":    {GrantRefreshToken},
+		"refresh_token": {session.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshed := body.Session()
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken, refreshed.RefreshExpiresAt = session.RefreshToken, session.RefreshExpiresAt
+	}
+	return refreshed, nil
+}
+
+// RevokeToken revokes an access or refresh token (RFC 7009); tokenTypeHint is
+// "access_token", "refresh_token" or empty. Revoking a refresh token also ends
+// the access tokens issued with it.
+func RevokeToken(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, token, tokenTypeHint string) error {
+	_, err := postTokenForm(ctx, settings, discovery, func(e Endpoints) string { return e.Revocation }, token, tokenTypeHint)
+	if err != nil {
+		return utils.WrapError("auth.revoke", "Failed to revoke token", err)
+	}
+	return nil
+}
+
+// IntrospectToken asks the authorization server whether token is active and
+// what it grants (RFC 7662); tokenTypeHint is as for RevokeToken
+func IntrospectToken(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, token, tokenTypeHint string) (*types.TokenIntrospection, error) {
+	resp, err := postTokenForm(ctx, settings, discovery, func(e Endpoints) string { return e.Introspection }, token, tokenTypeHint)
+	if err != nil {
+		return nil, utils.WrapError("auth.introspect", "Failed to introspect token", err)
+	}
+	var result types.TokenIntrospection
+	if err := resp.JSON(&result); err != nil {
+		return nil, utils.NewError("auth.introspect", nil, "Failed to parse introspection response", err)
+	}
+	if err := resp.JSON(&result.Raw); err != nil {
+		return nil, utils.NewError("auth.introspect", nil, "Failed to parse introspection response", err)
+	}
+	return &result, nil
+}
+
+// postTokenForm sends token to the revocation or introspection endpoint picked
+// by endpoint, authenticated as the client of settings
+func postTokenForm(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, endpoint func(Endpoints) string, token, tokenTypeHint string) (*utils.FetchXResponse, error) {
+	if token == "" {
+		return nil, &utils.ValidationError{Field: "Token", Message: "token is required"}
+	}
+	discovery = discoveryOrNew(settings, discovery)
+	form := url.Values{"token": {token}}
+	if tokenTypeHint != "" {
+		form.Set("token_type_hint", tokenTypeHint)
+	}
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	if err := AuthenticateClient(ctx, settings, discovery, headers, form); err != nil {
+		return nil, err
+	}
+	return utils.FetchXWithContext(ctx, endpoint(discovery.Endpoints(ctx)), utils.FetchXInput{
+		Method:   "POST",
+		Headers:  headers,
+		Body:     form.Encode(),
+		Client:   utils.HTTPClient(settings),
+		Retry:    settings.RetryPolicy,
+		Logger:   utils.Logger(settings),
+		Redactor: utils.RedactorFor(settings),
+		Tracer:   utils.Tracer(settings),
+		// revoking twice is harmless and introspection only reads
+		Idempotent: true,
+	})
+}
+
+// ClientCredentials obtains an application session for scope
+func ClientCredentials(ctx context.Context, settings types.GlideSdkSettings, discovery *Discovery, scope string) (*types.Session, error) {
+	body, err := RequestToken(ctx, settings, discovery, url.Values{
+		"grant_type": {GrantClientCredentials},
+		"scope":      {scope},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body.Session(), nil
+}
@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultRefreshWindow is how long before expiry a cached session is refreshed in the background
+	DefaultRefreshWindow = 5 * time.Minute
+	// minValidity is the remaining lifetime below which a renewable session is no
+	// longer handed out, a quarter of the lifetime for short-lived sessions
+	minValidity = time.Minute
+	// minSweep is how many sessions are kept in memory before expired ones are first swept
+	minSweep = 64
+)
+
+// TokenKey identifies a cached session
+type TokenKey struct {
+	GrantType string
+	Scope     string
+	LoginHint string
+}
+
+func (k TokenKey) String() string {
+	return k.GrantType + "|" + k.Scope + "|" + k.LoginHint
+}
+
+// logAttrs leaves out the login hint, which identifies a subscriber
+func (k TokenKey) logAttrs() []any {
+	return []any{"grantType", k.GrantType, "scope", k.Scope}
+}
+
+// FetchFunc obtains a new session from the token endpoint
+type FetchFunc func(ctx context.Context) (*types.Session, error)
+
+type tokenEntry struct {
+	session   *types.Session
+	fetchedAt time.Time
+}
+
+type tokenCall struct {
+	done    chan struct{}
+	session *types.Session
+	err     error
+}
+
+// TokenManager caches sessions per TokenKey and is safe for concurrent use.
+// Concurrent requests for the same key share a single fetch, and sessions that
+// can be renewed without the user are refreshed in the background, close to
+// expiry, while the cached one is still served.
+// When a SessionStore is configured it is consulted before every fetch and
+// updated after it, so other processes sharing the store reuse the session.
+type TokenManager struct {
+	clientID      string
+	store         types.SessionStore
+	logger        *slog.Logger
+	tracer        trace.Tracer
+	refreshWindow time.Duration
+
+	mu       sync.Mutex
+	sessions map[TokenKey]tokenEntry
+	inflight map[TokenKey]*tokenCall
+	// sweepAt is the number of cached sessions at which expired ones are dropped
+	sweepAt int
+}
+
+// NewTokenManager creates an empty TokenManager for the client and session
+// store in settings, using DefaultRefreshWindow
+func NewTokenManager(settings types.GlideSdkSettings) *TokenManager {
+	return &TokenManager{
+		clientID:      settings.ClientID,
+		store:         settings.SessionStore,
+		logger:        utils.Logger(settings),
+		tracer:        utils.Tracer(settings),
+		refreshWindow: DefaultRefreshWindow,
+		sessions:      map[TokenKey]tokenEntry{},
+		inflight:      map[TokenKey]*tokenCall{},
+		sweepAt:       minSweep,
+	}
+}
+
+// Get returns the cached session for key, calling fetch when there is none or it is about to expire
+func (m *TokenManager) Get(ctx context.Context, key TokenKey, fetch FetchFunc) (*types.Session, error) {
+	ctx, span := utils.StartSpan(ctx, m.tracer, "auth.session", trace.WithAttributes(
+		attribute.String("glide.grant_type", key.GrantType),
+		attribute.String("glide.scope", key.Scope),
+	))
+	session, cached, err := m.get(ctx, key, fetch)
+	span.SetAttributes(attribute.Bool("glide.session.cached", cached))
+	utils.EndSpan(span, err)
+	return session, err
+}
+
+// get is Get, also telling whether the session came from memory
+func (m *TokenManager) get(ctx context.Context, key TokenKey, fetch FetchFunc) (*types.Session, bool, error) {
+	now := time.Now()
+	m.mu.Lock()
+	if entry, ok := m.sessions[key]; ok && usable(key, entry, now) {
+		if renewable(key, entry.session) && now.After(m.refreshAt(entry)) {
+			m.logger.DebugContext(ctx, "refreshing session in the background", key.logAttrs()...)
+			// nobody waits for this refresh, so it must not share the caller's deadline
+			m.startFetch(context.WithoutCancel(ctx), key, fetch)
+		}
+		m.mu.Unlock()
+		m.logger.DebugContext(ctx, "using cached session", key.logAttrs()...)
+		return entry.session, true, nil
+	}
+	call := m.startFetch(ctx, key, fetch)
+	m.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.session, false, call.err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// Lookup returns a usable session for key from memory or the session store
+// without fetching a new one, or nil if there is none
+func (m *TokenManager) Lookup(ctx context.Context, key TokenKey) *types.Session {
+	m.mu.Lock()
+	entry, ok := m.sessions[key]
+	m.mu.Unlock()
+	if ok && usable(key, entry, time.Now()) {
+		return entry.session
+	}
+	session := m.load(ctx, key, 0)
+	if session != nil {
+		m.mu.Lock()
+		m.remember(key, session)
+		m.mu.Unlock()
+	}
+	return session
+}
+
+// Latest returns the newest session for key from memory or the session store,
+// even if it expired, e.g. to renew it with its refresh token; nil if there is
+// none. Expired sessions only stay in memory until the next sweep.
+func (m *TokenManager) Latest(ctx context.Context, key TokenKey) *types.Session {
+	m.mu.Lock()
+	latest := m.sessions[key].session
+	m.mu.Unlock()
+	if m.store == nil {
+		return latest
+	}
+	// another process may have renewed it, rotating the refresh token
+	stored, err := m.store.Get(ctx, m.storeKey(key))
+	if err != nil {
+		m.logger.WarnContext(ctx, "reading session store failed", append(key.logAttrs(), "error", err)...)
+	}
+	if stored != nil && (latest == nil || stored.ExpiresAt > latest.ExpiresAt) {
+		latest = stored
+	}
+	return latest
+}
+
+// Invalidate drops the session for key from memory and the session store, e.g.
+// after the API rejected it
+func (m *TokenManager) Invalidate(ctx context.Context, key TokenKey) error {
+	m.mu.Lock()
+	delete(m.sessions, key)
+	m.mu.Unlock()
+	if m.store == nil {
+		return nil
+	}
+	return m.store.Delete(ctx, m.storeKey(key))
+}
+
+// startFetch joins the in-flight fetch for key or starts a new one; m.mu must be held.
+// The fetch keeps running if the caller that started it gives up, so other waiters
+// still get its result, but it never outlives the caller's deadline.
+func (m *TokenManager) startFetch(ctx context.Context, key TokenKey, fetch FetchFunc) *tokenCall {
+	if call, ok := m.inflight[key]; ok {
+		return call
+	}
+	call := &tokenCall{done: make(chan struct{})}
+	m.inflight[key] = call
+	var current int64
+	if entry, ok := m.sessions[key]; ok {
+		current = entry.session.ExpiresAt
+	}
+
+	fetchCtx, cancel := detach(ctx)
+	go func() {
+		defer cancel()
+		var err error
+		session := m.load(fetchCtx, key, current)
+		if session != nil {
+			m.logger.DebugContext(fetchCtx, "using stored session", key.logAttrs()...)
+		} else {
+			m.logger.DebugContext(fetchCtx, "fetching new session", key.logAttrs()...)
+			session, err = fetch(fetchCtx)
+			if err == nil {
+				m.save(fetchCtx, key, session)
+			} else {
+				m.logger.DebugContext(fetchCtx, "session fetch failed", append(key.logAttrs(), "error", err)...)
+			}
+		}
+		m.mu.Lock()
+		delete(m.inflight, key)
+		if err == nil {
+			m.remember(key, session)
+		}
+		m.mu.Unlock()
+		call.session, call.err = session, err
+		close(call.done)
+	}()
+	return call
+}
+
+// remember caches session for key; m.mu must be held. Sessions are kept per
+// subscriber, so once the cache doubled since the last sweep the expired ones
+// are dropped, bounding it by the subscribers seen within a session lifetime.
+func (m *TokenManager) remember(key TokenKey, session *types.Session) {
+	now := time.Now()
+	m.sessions[key] = tokenEntry{session: session, fetchedAt: now}
+	if len(m.sessions) < m.sweepAt {
+		return
+	}
+	for k, entry := range m.sessions {
+		if expired(entry.session, now) {
+			delete(m.sessions, k)
+		}
+	}
+	m.sweepAt = max(2*len(m.sessions), minSweep)
+}
+
+// load returns the stored session for key if it is usable and outlives the
+// one expiring at current. Store failures only cost an extra fetch.
+func (m *TokenManager) load(ctx context.Context, key TokenKey, current int64) *types.Session {
+	if m.store == nil {
+		return nil
+	}
+	session, err := m.store.Get(ctx, m.storeKey(key))
+	if err != nil {
+		m.logger.WarnContext(ctx, "reading session store failed", append(key.logAttrs(), "error", err)...)
+	}
+	now := time.Now()
+	if err != nil || !usable(key, tokenEntry{session: session, fetchedAt: now}, now) || session.ExpiresAt <= current {
+		return nil
+	}
+	return session
+}
+
+// save stores session until it expires, or its refresh token does; a failed
+// write only costs other processes a fetch
+func (m *TokenManager) save(ctx context.Context, key TokenKey, session *types.Session) {
+	if m.store == nil {
+		return
+	}
+	if ttl := SessionTTL(session); ttl > 0 {
+		if err := m.store.Put(ctx, m.storeKey(key), session, ttl); err != nil {
+			m.logger.WarnContext(ctx, "writing session store failed", append(key.logAttrs(), "error", err)...)
+		}
+	}
+}
+
+// storeKey namespaces key by client so tenants sharing a store never see each other's sessions
+func (m *TokenManager) storeKey(key TokenKey) string {
+	return "glide|" + m.clientID + "|" + key.String()
+}
+
+// refreshAt is when a background refresh should start: refreshWindow before
+// expiry, or halfway through the lifetime of short-lived sessions
+func (m *TokenManager) refreshAt(entry tokenEntry) time.Time {
+	expiresAt := time.Unix(entry.session.ExpiresAt, 0)
+	window := m.refreshWindow
+	if lifetime := expiresAt.Sub(entry.fetchedAt); lifetime/2 < window {
+		window = lifetime / 2
+	}
+	return expiresAt.Add(-window)
+}
+
+// renewable tells whether a new session for key can be had without the user,
+// by client credentials or a refresh token. Fetching a CIBA session again would
+// prompt the subscriber for consent, so it is kept until it expires instead.
+func renewable(key TokenKey, session *types.Session) bool {
+	return key.GrantType == GrantClientCredentials || session.RefreshToken != ""
+}
+
+// usable tells whether the session of entry may still be handed out: renewable
+// ones until minValidity, or a quarter of their lifetime, before expiry, so
+// refreshAt always comes first; others until they expire
+func usable(key TokenKey, entry tokenEntry, now time.Time) bool {
+	session := entry.session
+	if session == nil {
+		return false
+	}
+	expiresAt := time.Unix(session.ExpiresAt, 0)
+	if renewable(key, session) {
+		margin := min(minValidity, expiresAt.Sub(entry.fetchedAt)/4)
+		return now.Before(expiresAt.Add(-margin))
+	}
+	return now.Before(expiresAt)
+}
+
+// expired tells whether session can neither be used nor renewed any more. A
+// refresh token of unknown lifetime is not kept past its access token, as in
+// the session store.
+func expired(session *types.Session, now time.Time) bool {
+	if session.ExpiresAt > now.Unix() {
+		return false
+	}
+	return session.RefreshToken == "" || session.RefreshExpiresAt <= now.Unix()
+}
+
+// detach returns a context carrying ctx's values and deadline but not its cancellation
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return utils.WithTimeout(detached, utils.DefaultTimeout)
+}
@@ -0,0 +1,183 @@
+// Package config loads SDK settings from a YAML or JSON file with
+// per-environment profiles and GLIDE_* environment variable overrides.
+//
+// A file sets shared values at the top level and environment specific ones
+// under profiles, which override them:
+//
+//	clientId: my-client
+//	profile: sandbox
+//	profiles:
+//	  sandbox:
+//	    authBaseUrl: https://oidc.sandbox.example.com
+//	    apiBaseUrl: https://api.sandbox.example.com
+//	  production:
+//	    timeout: 10s
+//
+// JSON files use the same keys.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"go.yaml.in/yaml/v3"
+)
+
+// Profiles of the deployment environments
+const (
+	Sandbox    = "sandbox"
+	Staging    = "staging"
+	Production = "production"
+)
+
+// Values are the settings a file, a profile or the environment can set; empty
+// ones are left to the next layer
+type Values struct {
+	ClientID     string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	RedirectURI  string `yaml:"redirectUri"`
+	AuthBaseURL  string `yaml:"authBaseUrl"`
+	APIBaseURL   string `yaml:"apiBaseUrl"`
+	// Timeout is a Go duration, e.g. "10s"
+	Timeout          string `yaml:"timeout"`
+	DefaultRegion    string `yaml:"defaultRegion"`
+	CIBADeliveryMode string `yaml:"cibaDeliveryMode"`
+}
+
+// File is the layout of a configuration file
+type File struct {
+	Values `yaml:",inline"`
+	// Profile is used when neither the caller nor GLIDE_PROFILE choose one
+	Profile  string            `yaml:"profile"`
+	Profiles map[string]Values `yaml:"profiles"`
+}
+
+// Load reads the configuration file at path and returns the validated settings
+// of profile. An empty profile falls back to GLIDE_PROFILE and then to the
+// profile named in the file; without any only the top level values apply.
+func Load(path, profile string) (types.GlideSdkSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return types.GlideSdkSettings{}, utils.NewError("config.load", nil, "Failed to read config file", err)
+	}
+	return Parse(data, profile)
+}
+
+// Parse is like Load for the contents of a YAML or JSON file
+func Parse(data []byte, profile string) (types.GlideSdkSettings, error) {
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// a misspelt key would otherwise silently fall back to a default
+	decoder.KnownFields(true)
+	// JSON is valid YAML, so both formats decode alike
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return types.GlideSdkSettings{}, utils.NewError("config.load", utils.ErrValidation, "Failed to parse config file", err)
+	}
+
+	values := file.Values
+	if profile == "" {
+		profile = os.Getenv("GLIDE_PROFILE")
+	}
+	if profile == "" {
+		profile = file.Profile
+	}
+	if profile != "" {
+		override, ok := file.Profiles[profile]
+		if !ok {
+			return types.GlideSdkSettings{}, &utils.ValidationError{
+				Field:   "Profile",
+				Message: fmt.Sprintf("profile %q is not defined, the config has %v", profile, profileNames(file)),
+			}
+		}
+		values = merge(values, override)
+	}
+	values = merge(values, envValues())
+	return values.settings()
+}
+
+// settings converts values, falling back to the production endpoints, and
+// validates the result
+func (v Values) settings() (types.GlideSdkSettings, error) {
+	settings := types.GlideSdkSettings{
+		ClientID:         v.ClientID,
+		ClientSecret:     v.ClientSecret,
+		RedirectURI:      v.RedirectURI,
+		DefaultRegion:    v.DefaultRegion,
+		CIBADeliveryMode: types.CIBADeliveryMode(v.CIBADeliveryMode),
+		Internal: types.InternalSettings{
+			AuthBaseURL: v.AuthBaseURL,
+			APIBaseURL:  v.APIBaseURL,
+		},
+	}
+	if settings.Internal.AuthBaseURL == "" {
+		settings.Internal.AuthBaseURL = glide.DefaultAuthBaseURL
+	}
+	if settings.Internal.APIBaseURL == "" {
+		settings.Internal.APIBaseURL = glide.DefaultAPIBaseURL
+	}
+
+	var errs []error
+	if v.Timeout != "" {
+		timeout, err := time.ParseDuration(v.Timeout)
+		if err != nil {
+			errs = append(errs, &utils.ValidationError{Field: "Timeout", Message: fmt.Sprintf("timeout %q is not a duration", v.Timeout)})
+		}
+		settings.Timeout = timeout
+	}
+	if err := glide.Validate(settings); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return types.GlideSdkSettings{}, err
+	}
+	return settings, nil
+}
+
+// envValues reads the GLIDE_* variables that override the file
+func envValues() Values {
+	return Values{
+		ClientID:         os.Getenv("GLIDE_CLIENT_ID"),
+		ClientSecret:     os.Getenv("GLIDE_CLIENT_SECRET"),
+		RedirectURI:      os.Getenv("GLIDE_REDIRECT_URI"),
+		AuthBaseURL:      os.Getenv("GLIDE_AUTH_BASE_URL"),
+		APIBaseURL:       os.Getenv("GLIDE_API_BASE_URL"),
+		Timeout:          os.Getenv("GLIDE_TIMEOUT"),
+		DefaultRegion:    os.Getenv("GLIDE_DEFAULT_REGION"),
+		CIBADeliveryMode: os.Getenv("GLIDE_CIBA_DELIVERY_MODE"),
+	}
+}
+
+// merge returns base with the values set in override
+func merge(base, override Values) Values {
+	set := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	set(&base.ClientID, override.ClientID)
+	set(&base.ClientSecret, override.ClientSecret)
+	set(&base.RedirectURI, override.RedirectURI)
+	set(&base.AuthBaseURL, override.AuthBaseURL)
+	set(&base.APIBaseURL, override.APIBaseURL)
+	set(&base.Timeout, override.Timeout)
+	set(&base.DefaultRegion, override.DefaultRegion)
+	set(&base.CIBADeliveryMode, override.CIBADeliveryMode)
+	return base
+}
+
+func profileNames(file File) []string {
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,10 +1,12 @@
 package glide
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
 	"github.com/ClearBlockchain/sdk-go/pkg/services"
 	"github.com/ClearBlockchain/sdk-go/pkg/types"
 	"github.com/ClearBlockchain/sdk-go/pkg/utils"
@@ -13,6 +15,9 @@ import (
 // GlideClient is the main client for the SDK
 type GlideClient struct {
 	settings    types.GlideSdkSettings
+	tokens      *auth.TokenManager
+	metrics     types.MetricSink
+	discovery   *auth.Discovery
 	TelcoFinder *services.TelcoFinderClient
 	MagicAuth   *services.MagicAuthClient
 	SimSwap     *services.SimSwapClient
@@ -45,44 +50,107 @@ func ReportMetric(report types.MetricInfo) error{
 	return nil
 }
 
+// NewGlideClient creates a client from settings, filling the fields it leaves
+// unset from the GLIDE_* environment variables as if UseEnv were set
 func NewGlideClient(settings types.GlideSdkSettings) (*GlideClient, error) {
+	settings.UseEnv = true
+	return newClient(settings)
+}
+
+// New creates a client configured by opts, connecting to the production gateway
+// unless WithEndpoints says otherwise. The environment is only read with WithEnv.
+// All configuration problems are reported at once, see Validate.
+func New(opts ...Option) (*GlideClient, error) {
+	var settings types.GlideSdkSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return newClient(settings)
+}
+
+func newClient(settings types.GlideSdkSettings) (*GlideClient, error) {
 	defaults := types.GlideSdkSettings{
-		ClientID:     os.Getenv("GLIDE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GLIDE_CLIENT_SECRET"),
-		RedirectURI:  os.Getenv("GLIDE_REDIRECT_URI"),
 		Internal: types.InternalSettings{
-			AuthBaseURL: getEnvOrDefault("GLIDE_AUTH_BASE_URL", "https://oidc.gateway-x.io"),
-			APIBaseURL:  getEnvOrDefault("GLIDE_API_BASE_URL", "https://api.gateway-x.io"),
+			AuthBaseURL: DefaultAuthBaseURL,
+			APIBaseURL:  DefaultAPIBaseURL,
 		},
 	}
+	if settings.UseEnv {
+		defaults = mergeSettings(defaults, envSettings())
+	}
 
 	// Merge defaults with provided settings
 	mergedSettings := mergeSettings(defaults, settings)
-
-	if mergedSettings.ClientID == "" {
-		return nil, errors.New("clientId is required")
-	}
-
-	if mergedSettings.Internal.AuthBaseURL == "" {
-		return nil, errors.New("internal.authBaseUrl is unset")
+	if err := Validate(mergedSettings); err != nil {
+		return nil, err
 	}
 
+	// one cache for all sub-clients so they never fetch the same token twice
+	tokens := auth.NewTokenManager(mergedSettings)
+	metrics := utils.MetricSinkFor(mergedSettings)
+	// fetched once for all sub-clients, through this client's transport
+	discovery := auth.NewDiscovery(mergedSettings)
+	shared := []services.ClientOption{services.WithTokenManager(tokens), services.WithMetricSink(metrics), services.WithDiscovery(discovery)}
 	client := &GlideClient{
 		settings:    mergedSettings,
-		TelcoFinder: services.NewTelcoFinderClient(mergedSettings),
-		MagicAuth:   services.NewMagicAuthClient(mergedSettings),
-		SimSwap:     services.NewSimSwapClient(mergedSettings),
-		NumberVerify: services.NewNumberVerifyClient(mergedSettings),
+		discovery:   discovery,
+		tokens:      tokens,
+		metrics:     metrics,
+		TelcoFinder: services.NewTelcoFinderClient(mergedSettings, shared...),
+		MagicAuth:   services.NewMagicAuthClient(mergedSettings, shared...),
+		SimSwap:     services.NewSimSwapClient(mergedSettings, shared...),
+		NumberVerify: services.NewNumberVerifyClient(mergedSettings, shared...),
 	}
 
 	return client, nil
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// MetricStats reports how many metrics are queued, sent, dropped or failed by
+// the default metric reporter; it is zero when settings.MetricSink replaced it
+func (c *GlideClient) MetricStats() utils.MetricStats {
+	if reporter, ok := c.metrics.(*utils.AsyncMetricReporter); ok {
+		return reporter.Stats()
+	}
+	return utils.MetricStats{}
+}
+
+// Close flushes and closes the metric sink, waiting until the metrics still
+// queued are sent or ctx is done. The client must not be used afterwards.
+func (c *GlideClient) Close(ctx context.Context) error {
+	return utils.CloseMetricSink(ctx, c.metrics)
+}
+
+// Revoke revokes an access or refresh token at the authorization server (RFC
+// 7009); tokenTypeHint is "access_token", "refresh_token" or empty
+func (c *GlideClient) Revoke(token, tokenTypeHint string) error {
+	return c.RevokeWithContext(context.Background(), token, tokenTypeHint)
+}
+
+// RevokeWithContext is like Revoke but aborts when ctx is cancelled
+func (c *GlideClient) RevokeWithContext(ctx context.Context, token, tokenTypeHint string) error {
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout())
+	defer cancel()
+	return auth.RevokeToken(ctx, c.settings, c.discovery, token, tokenTypeHint)
+}
+
+// Introspect asks the authorization server whether token, e.g. the access token
+// of a Session passed in ApiConfig, is still active and what it grants (RFC 7662)
+func (c *GlideClient) Introspect(token string) (*types.TokenIntrospection, error) {
+	return c.IntrospectWithContext(context.Background(), token)
+}
+
+// IntrospectWithContext is like Introspect but aborts when ctx is cancelled
+func (c *GlideClient) IntrospectWithContext(ctx context.Context, token string) (*types.TokenIntrospection, error) {
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout())
+	defer cancel()
+	return auth.IntrospectToken(ctx, c.settings, c.discovery, token, "")
+}
+
+func (c *GlideClient) timeout() time.Duration {
+	if c.settings.Timeout != 0 {
+		return c.settings.Timeout
 	}
-	return defaultValue
+	return utils.DefaultTimeout
 }
 
 func mergeSettings(defaults, override types.GlideSdkSettings) types.GlideSdkSettings {
@@ -97,6 +165,51 @@ func mergeSettings(defaults, override types.GlideSdkSettings) types.GlideSdkSett
 	if override.RedirectURI != "" {
 		result.RedirectURI = override.RedirectURI
 	}
+	if override.UseEnv {
+		result.UseEnv = true
+	}
+	if override.Timeout != 0 {
+		result.Timeout = override.Timeout
+	}
+	if override.HTTPClient != nil {
+		result.HTTPClient = override.HTTPClient
+	}
+	if override.Transport != nil {
+		result.Transport = override.Transport
+	}
+	if override.SessionStore != nil {
+		result.SessionStore = override.SessionStore
+	}
+	if override.RetryPolicy != nil {
+		result.RetryPolicy = override.RetryPolicy
+	}
+	if override.Logger != nil {
+		result.Logger = override.Logger
+	}
+	if override.Redaction != (types.RedactionPolicy{}) {
+		result.Redaction = override.Redaction
+	}
+	if override.ClientAuth.Method != "" || override.ClientAuth.SigningKey != nil || override.ClientAuth.Certificate != nil {
+		result.ClientAuth = override.ClientAuth
+	}
+	if override.AuthStateStore != nil {
+		result.AuthStateStore = override.AuthStateStore
+	}
+	if override.DefaultRegion != "" {
+		result.DefaultRegion = override.DefaultRegion
+	}
+	if override.MetricSink != nil {
+		result.MetricSink = override.MetricSink
+	}
+	if override.TracerProvider != nil {
+		result.TracerProvider = override.TracerProvider
+	}
+	if override.CIBADeliveryMode != "" {
+		result.CIBADeliveryMode = override.CIBADeliveryMode
+	}
+	if override.Metrics != (types.MetricsPolicy{}) {
+		result.Metrics = override.Metrics
+	}
 	if override.Internal.AuthBaseURL != "" {
 		result.Internal.AuthBaseURL = override.Internal.AuthBaseURL
 	}
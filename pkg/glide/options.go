@@ -0,0 +1,128 @@
+package glide
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+// Endpoints of the production gateway, used unless WithEndpoints or the
+// environment name others
+const (
+	DefaultAuthBaseURL = "https://oidc.gateway-x.io"
+	DefaultAPIBaseURL  = "https://api.gateway-x.io"
+)
+
+// Option configures a GlideClient created with New
+type Option func(*types.GlideSdkSettings)
+
+// WithSettings applies the fields set in settings, e.g. ones without an option
+// of their own; options after it override them
+func WithSettings(settings types.GlideSdkSettings) Option {
+	return func(s *types.GlideSdkSettings) {
+		*s = mergeSettings(*s, settings)
+	}
+}
+
+// WithEnv fills the settings no option sets from the GLIDE_CLIENT_ID,
+// GLIDE_CLIENT_SECRET, GLIDE_REDIRECT_URI, GLIDE_AUTH_BASE_URL and
+// GLIDE_API_BASE_URL environment variables
+func WithEnv() Option {
+	return func(s *types.GlideSdkSettings) {
+		s.UseEnv = true
+	}
+}
+
+// WithCredentials sets the client ID and secret issued by Glide
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.ClientID = clientID
+		s.ClientSecret = clientSecret
+	}
+}
+
+// WithClientAuth authenticates the client with a private key or certificate
+// instead of a secret
+func WithClientAuth(clientAuth types.ClientAuth) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.ClientAuth = clientAuth
+	}
+}
+
+// WithRedirectURI sets where number verification sends the user back to
+func WithRedirectURI(redirectURI string) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.RedirectURI = redirectURI
+	}
+}
+
+// WithEndpoints sets the base URLs of the authorization server and the API,
+// e.g. of a sandbox; an empty one keeps the default
+func WithEndpoints(authBaseURL, apiBaseURL string) Option {
+	return func(s *types.GlideSdkSettings) {
+		if authBaseURL != "" {
+			s.Internal.AuthBaseURL = authBaseURL
+		}
+		if apiBaseURL != "" {
+			s.Internal.APIBaseURL = apiBaseURL
+		}
+	}
+}
+
+// WithTransport sends every request through transport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.Transport = transport
+	}
+}
+
+// WithHTTPClient sends every request through client, taking precedence over WithTransport
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.HTTPClient = client
+	}
+}
+
+// WithLogger sends the structured debug and warning events of the SDK to logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.Logger = logger
+	}
+}
+
+// WithMetricSink reports usage metrics to sink instead of REPORT_METRIC_URL
+func WithMetricSink(sink types.MetricSink) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.MetricSink = sink
+	}
+}
+
+// WithTimeout bounds every service call that does not set ApiConfig.Timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.Timeout = timeout
+	}
+}
+
+// WithRetryPolicy controls how idempotent requests are retried
+func WithRetryPolicy(policy types.RetryPolicy) Option {
+	return func(s *types.GlideSdkSettings) {
+		s.RetryPolicy = &policy
+	}
+}
+
+// envSettings reads the settings WithEnv fills in
+func envSettings() types.GlideSdkSettings {
+	return types.GlideSdkSettings{
+		ClientID:     os.Getenv("GLIDE_CLIENT_ID"),
+		ClientSecret: os.Getenv("GLIDE_CLIENT_SECRET"),
+		RedirectURI:  os.Getenv("GLIDE_REDIRECT_URI"),
+		Internal: types.InternalSettings{
+			AuthBaseURL: os.Getenv("GLIDE_AUTH_BASE_URL"),
+			APIBaseURL:  os.Getenv("GLIDE_API_BASE_URL"),
+		},
+	}
+}
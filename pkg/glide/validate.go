@@ -0,0 +1,68 @@
+package glide
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+)
+
+// Validate checks settings before a client is created with them and reports
+// every problem at once, each a *utils.ValidationError joined with errors.Join.
+// The client secret is not required, since calls may bring their own session.
+func Validate(settings types.GlideSdkSettings) error {
+	var errs []error
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, &utils.ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if settings.ClientID == "" {
+		invalid("ClientID", "clientId is required")
+	}
+	if settings.Internal.AuthBaseURL == "" {
+		invalid("Internal.AuthBaseURL", "internal.authBaseUrl is unset")
+	} else if !validURL(settings.Internal.AuthBaseURL) {
+		invalid("Internal.AuthBaseURL", "internal.authBaseUrl %q is not an absolute http(s) URL", settings.Internal.AuthBaseURL)
+	}
+	if settings.Internal.APIBaseURL == "" {
+		invalid("Internal.APIBaseURL", "internal.apiBaseUrl is unset")
+	} else if !validURL(settings.Internal.APIBaseURL) {
+		invalid("Internal.APIBaseURL", "internal.apiBaseUrl %q is not an absolute http(s) URL", settings.Internal.APIBaseURL)
+	}
+	if settings.RedirectURI != "" && !absoluteURI(settings.RedirectURI) {
+		invalid("RedirectURI", "redirectUri %q is not an absolute URI", settings.RedirectURI)
+	}
+	if settings.Timeout < 0 {
+		invalid("Timeout", "timeout must not be negative")
+	}
+	if policy := settings.RetryPolicy; policy != nil && (policy.MaxAttempts < 0 || policy.BaseDelay < 0 || policy.MaxDelay < 0) {
+		invalid("RetryPolicy", "retry attempts and delays must not be negative")
+	}
+	switch settings.CIBADeliveryMode {
+	case "", types.CIBAPoll, types.CIBAPing, types.CIBAPush:
+	default:
+		invalid("CIBADeliveryMode", "unknown CIBA delivery mode %q", settings.CIBADeliveryMode)
+	}
+	// a secret is checked when a session is fetched, other methods up front
+	if method := settings.ClientAuth.Method; settings.ClientID != "" && method != "" && method != types.ClientSecretBasic {
+		if err := auth.ValidateClientAuth(settings); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// absoluteURI accepts any scheme, as native apps redirect to private-use ones
+// such as com.example.app:/callback (RFC 8252)
+func absoluteURI(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.IsAbs()
+}
@@ -0,0 +1,59 @@
+// Package otelmetrics exposes the SDK's call metrics as OpenTelemetry instruments.
+package otelmetrics
+
+import (
+	"context"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/ClearBlockchain/sdk-go"
+
+// Sink is a types.MetricSink counting calls and recording their latency, by
+// API, operation, operator and outcome. Funnel metrics, those without an
+// outcome, are ignored.
+type Sink struct {
+	calls   metric.Int64Counter
+	latency metric.Float64Histogram
+}
+
+// New creates a Sink recording to a meter of provider; a nil provider uses the
+// global one
+func New(provider metric.MeterProvider) (*Sink, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	meter := provider.Meter(instrumentationName)
+	calls, err := meter.Int64Counter("glide.sdk.calls",
+		metric.WithDescription("Glide API calls made by the SDK"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		return nil, err
+	}
+	latency, err := meter.Float64Histogram("glide.sdk.call.duration",
+		metric.WithDescription("Duration of Glide API calls made by the SDK"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{calls: calls, latency: latency}, nil
+}
+
+// Report records m if it is the completion of a call
+func (s *Sink) Report(m types.MetricInfo) {
+	if m.Outcome == "" {
+		return
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("glide.api", m.Api),
+		attribute.String("glide.operation", m.MetricName),
+		attribute.String("glide.operator", m.Operator),
+		attribute.String("glide.outcome", m.Outcome),
+	)
+	ctx := context.Background()
+	s.calls.Add(ctx, 1, attrs)
+	s.latency.Record(ctx, m.Latency.Seconds(), attrs)
+}
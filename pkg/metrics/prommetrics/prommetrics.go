@@ -0,0 +1,60 @@
+// Package prommetrics exposes the SDK's call metrics as a Prometheus collector.
+package prommetrics
+
+import (
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var labels = []string{"api", "operation", "operator", "outcome"}
+
+// Collector is a types.MetricSink counting calls and recording their latency,
+// by API, operation, operator and outcome. Register it with a
+// prometheus.Registerer to export them. Funnel metrics, those without an
+// outcome, are ignored.
+type Collector struct {
+	calls   *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+}
+
+// New creates a Collector whose metrics are prefixed with namespace, e.g.
+// "myapp" exports myapp_glide_calls_total
+func New(namespace string) *Collector {
+	return &Collector{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "glide",
+			Name:      "calls_total",
+			Help:      "Glide API calls made by the SDK.",
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "glide",
+			Name:      "call_duration_seconds",
+			Help:      "Duration of Glide API calls made by the SDK.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+}
+
+// Report records m if it is the completion of a call
+func (c *Collector) Report(m types.MetricInfo) {
+	if m.Outcome == "" {
+		return
+	}
+	values := []string{m.Api, m.MetricName, m.Operator, m.Outcome}
+	c.calls.WithLabelValues(values...).Inc()
+	c.latency.WithLabelValues(values...).Observe(m.Latency.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.calls.Describe(ch)
+	c.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.calls.Collect(ch)
+	c.latency.Collect(ch)
+}
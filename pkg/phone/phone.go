@@ -0,0 +1,121 @@
+// Package phone parses and validates phone numbers into E.164 form before they
+// are sent to an operator.
+package phone
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+)
+
+// maxDigits is the E.164 limit on country code plus national number
+const maxDigits = 15
+
+// PhoneNumber is a validated phone number
+type PhoneNumber struct {
+	CountryCode    int
+	NationalNumber string
+}
+
+// E164 formats the number as "+" followed by country code and national number
+func (p PhoneNumber) E164() string {
+	return "+" + strconv.Itoa(p.CountryCode) + p.NationalNumber
+}
+
+func (p PhoneNumber) String() string {
+	return p.E164()
+}
+
+// Parse reads number in international ("+44 20 7946 0958", "0044...", "tel:+44...")
+// or, given a defaultRegion such as "GB", national ("020 7946 0958") format and
+// validates its country code and length
+func Parse(number, defaultRegion string) (PhoneNumber, error) {
+	raw := strings.TrimSpace(number)
+	raw = strings.TrimPrefix(raw, "tel:")
+	international := strings.HasPrefix(raw, "+")
+	digits, ok := stripSeparators(strings.TrimPrefix(raw, "+"))
+	if !ok {
+		return PhoneNumber{}, invalid("Phone number may only contain digits, spaces and -.()/ separators")
+	}
+	if digits == "" {
+		return PhoneNumber{}, invalid("Phone number is empty")
+	}
+
+	var region *Region
+	if defaultRegion != "" {
+		r, ok := regions[strings.ToUpper(defaultRegion)]
+		if !ok {
+			return PhoneNumber{}, &utils.ValidationError{Field: "DefaultRegion", Message: "Unknown default region " + defaultRegion}
+		}
+		region = &r
+	}
+
+	if !international {
+		switch {
+		case region != nil && region.IDD != "" && strings.HasPrefix(digits, region.IDD):
+			digits, international = digits[len(region.IDD):], true
+		case strings.HasPrefix(digits, "00"):
+			digits, international = digits[2:], true
+		}
+	}
+	if !international {
+		if region == nil {
+			return PhoneNumber{}, invalid("Phone number is not in international format and no default region is set")
+		}
+		national := strings.TrimPrefix(digits, region.TrunkPrefix)
+		return validate(region.CountryCode, national)
+	}
+
+	for n := 1; n <= 3 && n < len(digits); n++ {
+		code, _ := strconv.Atoi(digits[:n])
+		if countryCodes[code] {
+			return validate(code, digits[n:])
+		}
+	}
+	return PhoneNumber{}, invalid("Phone number has an unknown country code")
+}
+
+// Format parses number and returns its E.164 form
+func Format(number, defaultRegion string) (string, error) {
+	parsed, err := Parse(number, defaultRegion)
+	if err != nil {
+		return "", err
+	}
+	return parsed.E164(), nil
+}
+
+func validate(countryCode int, national string) (PhoneNumber, error) {
+	if national == "" || national[0] == '0' && !keepsLeadingZero[countryCode] {
+		return PhoneNumber{}, invalid("Phone number has an invalid national number")
+	}
+	minLen, maxLen := 4, maxDigits-len(strconv.Itoa(countryCode))
+	if r, ok := regionByCode[countryCode]; ok {
+		minLen, maxLen = r.MinLength, r.MaxLength
+	}
+	if len(national) < minLen {
+		return PhoneNumber{}, invalid("Phone number is too short")
+	}
+	if len(national) > maxLen {
+		return PhoneNumber{}, invalid("Phone number is too long")
+	}
+	return PhoneNumber{CountryCode: countryCode, NationalNumber: national}, nil
+}
+
+func stripSeparators(s string) (string, bool) {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')' || c == '/':
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+func invalid(message string) error {
+	return &utils.ValidationError{Field: "PhoneNumber", Message: message}
+}
@@ -0,0 +1,102 @@
+package phone
+
+// Region holds the dialing rules of a country
+type Region struct {
+	CountryCode int
+	// TrunkPrefix is dialed before national numbers within the country and dropped in international format
+	TrunkPrefix string
+	// IDD is the international call prefix besides the common "00"
+	IDD string
+	// MinLength and MaxLength bound the national number
+	MinLength, MaxLength int
+}
+
+// regions keyed by ISO 3166-1 alpha-2 code. Countries sharing a country code
+// must agree on its lengths, as international numbers are checked by code.
+var regions = map[string]Region{
+	"AE": {CountryCode: 971, TrunkPrefix: "0", MinLength: 8, MaxLength: 9},
+	"AR": {CountryCode: 54, TrunkPrefix: "0", MinLength: 10, MaxLength: 11},
+	"AT": {CountryCode: 43, TrunkPrefix: "0", MinLength: 4, MaxLength: 13},
+	"AU": {CountryCode: 61, TrunkPrefix: "0", IDD: "0011", MinLength: 9, MaxLength: 9},
+	"BE": {CountryCode: 32, TrunkPrefix: "0", MinLength: 8, MaxLength: 9},
+	"BR": {CountryCode: 55, TrunkPrefix: "0", MinLength: 10, MaxLength: 11},
+	"CA": {CountryCode: 1, TrunkPrefix: "1", IDD: "011", MinLength: 10, MaxLength: 10},
+	"CH": {CountryCode: 41, TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"CL": {CountryCode: 56, MinLength: 9, MaxLength: 9},
+	"CN": {CountryCode: 86, TrunkPrefix: "0", MinLength: 9, MaxLength: 11},
+	"CO": {CountryCode: 57, MinLength: 10, MaxLength: 10},
+	"DE": {CountryCode: 49, TrunkPrefix: "0", MinLength: 6, MaxLength: 13},
+	"DK": {CountryCode: 45, MinLength: 8, MaxLength: 8},
+	"ES": {CountryCode: 34, MinLength: 9, MaxLength: 9},
+	"FI": {CountryCode: 358, TrunkPrefix: "0", MinLength: 5, MaxLength: 12},
+	"FR": {CountryCode: 33, TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"GB": {CountryCode: 44, TrunkPrefix: "0", MinLength: 9, MaxLength: 10},
+	"GR": {CountryCode: 30, MinLength: 10, MaxLength: 10},
+	"IE": {CountryCode: 353, TrunkPrefix: "0", MinLength: 7, MaxLength: 9},
+	"IL": {CountryCode: 972, TrunkPrefix: "0", MinLength: 8, MaxLength: 9},
+	"IN": {CountryCode: 91, TrunkPrefix: "0", MinLength: 10, MaxLength: 10},
+	"IT": {CountryCode: 39, MinLength: 6, MaxLength: 11},
+	"JP": {CountryCode: 81, TrunkPrefix: "0", IDD: "010", MinLength: 9, MaxLength: 10},
+	"KR": {CountryCode: 82, TrunkPrefix: "0", MinLength: 8, MaxLength: 10},
+	"MX": {CountryCode: 52, MinLength: 10, MaxLength: 10},
+	"NG": {CountryCode: 234, TrunkPrefix: "0", MinLength: 8, MaxLength: 10},
+	"NL": {CountryCode: 31, TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"NO": {CountryCode: 47, MinLength: 8, MaxLength: 8},
+	"NZ": {CountryCode: 64, TrunkPrefix: "0", MinLength: 8, MaxLength: 10},
+	"PE": {CountryCode: 51, TrunkPrefix: "0", MinLength: 8, MaxLength: 9},
+	"PL": {CountryCode: 48, MinLength: 9, MaxLength: 9},
+	"PT": {CountryCode: 351, MinLength: 9, MaxLength: 9},
+	"RU": {CountryCode: 7, TrunkPrefix: "8", IDD: "810", MinLength: 10, MaxLength: 10},
+	"SE": {CountryCode: 46, TrunkPrefix: "0", MinLength: 7, MaxLength: 9},
+	"SG": {CountryCode: 65, MinLength: 8, MaxLength: 8},
+	"TR": {CountryCode: 90, TrunkPrefix: "0", MinLength: 10, MaxLength: 10},
+	"US": {CountryCode: 1, TrunkPrefix: "1", IDD: "011", MinLength: 10, MaxLength: 10},
+	"ZA": {CountryCode: 27, TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+}
+
+// keepsLeadingZero lists country codes whose national numbers keep their
+// leading 0 in international format
+var keepsLeadingZero = map[int]bool{39: true}
+
+var regionByCode = func() map[int]Region {
+	byCode := map[int]Region{}
+	for _, r := range regions {
+		byCode[r.CountryCode] = r
+	}
+	return byCode
+}()
+
+// countryCodes holds every country calling code assigned by ITU-T E.164
+var countryCodes = func() map[int]bool {
+	codes := map[int]bool{}
+	add := func(from, to int) {
+		for code := from; code <= to; code++ {
+			codes[code] = true
+		}
+	}
+	for _, code := range []int{
+		1, 7,
+		20, 27, 30, 31, 32, 33, 34, 36, 39, 40, 41, 43, 44, 45, 46, 47, 48, 49,
+		51, 52, 53, 54, 55, 56, 57, 58, 60, 61, 62, 63, 64, 65, 66,
+		81, 82, 84, 86, 90, 91, 92, 93, 94, 95, 98,
+		211, 212, 213, 216, 218, 290, 291, 297, 298, 299,
+		420, 421, 423, 670, 800, 808, 850, 852, 853, 855, 856, 870, 878,
+		880, 881, 882, 883, 886, 888, 970, 979, 998,
+	} {
+		codes[code] = true
+	}
+	add(220, 258)
+	add(260, 269)
+	add(350, 359)
+	add(370, 383)
+	add(385, 387)
+	codes[389] = true
+	add(500, 509)
+	add(590, 599)
+	add(672, 683)
+	add(685, 692)
+	add(960, 968)
+	add(971, 977)
+	add(992, 996)
+	return codes
+}()
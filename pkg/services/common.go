@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/phone"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// callContext bounds a service call by the timeout in conf, falling back to the
+// client settings and then utils.DefaultTimeout
+func callContext(ctx context.Context, settings types.GlideSdkSettings, conf types.ApiConfig) (context.Context, context.CancelFunc) {
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = settings.Timeout
+	}
+	if timeout == 0 {
+		timeout = utils.DefaultTimeout
+	}
+	return utils.WithTimeout(ctx, timeout)
+}
+
+// formatPhone validates number and returns it in E.164 form, reading national
+// numbers in the default region of settings
+func formatPhone(settings types.GlideSdkSettings, number string) (string, error) {
+	return phone.Format(number, settings.DefaultRegion)
+}
+
+// fetch sends a request through the HTTP client and retry policy configured in settings
+func fetch(ctx context.Context, settings types.GlideSdkSettings, url string, input utils.FetchXInput) (*utils.FetchXResponse, error) {
+	input.Client = utils.HTTPClient(settings)
+	input.Retry = settings.RetryPolicy
+	input.Logger = utils.Logger(settings)
+	input.Redactor = utils.RedactorFor(settings)
+	input.Tracer = utils.Tracer(settings)
+	return utils.FetchXWithContext(ctx, url, input)
+}
+
+// revokeSession revokes the session cached under key, through its refresh token
+// when it has one as that also ends its access tokens, and forgets it
+func revokeSession(ctx context.Context, settings types.GlideSdkSettings, discovery *auth.Discovery, tokens *auth.TokenManager, key auth.TokenKey) error {
+	session := tokens.Latest(ctx, key)
+	if session == nil {
+		return nil
+	}
+	if err := revoke(ctx, settings, discovery, session); err != nil {
+		return err
+	}
+	return tokens.Invalidate(ctx, key)
+}
+
+// revoke revokes session through its refresh token when it has one, as that
+// also ends its access tokens, or else through its access token
+func revoke(ctx context.Context, settings types.GlideSdkSettings, discovery *auth.Discovery, session *types.Session) error {
+	token, hint := session.AccessToken, "access_token"
+	if session.RefreshToken != "" {
+		token, hint = session.RefreshToken, "refresh_token"
+	}
+	return auth.RevokeToken(ctx, settings, discovery, token, hint)
+}
+
+// ClientOption shares state between the service clients of one GlideClient;
+// without options every client keeps its own
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	tokens    *auth.TokenManager
+	metrics   types.MetricSink
+	discovery *auth.Discovery
+}
+
+// WithTokenManager caches sessions in tokens, e.g. one shared with other clients
+// so they never fetch the same token twice
+func WithTokenManager(tokens *auth.TokenManager) ClientOption {
+	return func(o *clientOptions) {
+		o.tokens = tokens
+	}
+}
+
+// WithMetricSink reports usage metrics to sink instead of the sink of settings
+func WithMetricSink(sink types.MetricSink) ClientOption {
+	return func(o *clientOptions) {
+		o.metrics = sink
+	}
+}
+
+// WithDiscovery resolves the authorization endpoints through discovery, e.g. one
+// shared with other clients of the same settings so it is fetched once
+func WithDiscovery(discovery *auth.Discovery) ClientOption {
+	return func(o *clientOptions) {
+		o.discovery = discovery
+	}
+}
+
+// newClientOptions applies opts, giving the client its own session cache and
+// discovery and the metric sink of settings for what they leave unset
+func newClientOptions(settings types.GlideSdkSettings, opts []ClientOption) clientOptions {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.tokens == nil {
+		o.tokens = auth.NewTokenManager(settings)
+	}
+	if o.discovery == nil {
+		o.discovery = auth.NewDiscovery(settings)
+	}
+	if o.metrics == nil {
+		o.metrics = utils.MetricSinkFor(settings)
+	}
+	return o
+}
+
+// apiCall traces a public method and reports its outcome and latency when it
+// returns. Calls made for a session, see ApiConfig.SessionIdentifier, also
+// report the funnel steps of that session.
+type apiCall struct {
+	span      trace.Span
+	sink      types.MetricSink
+	clientID  string
+	sessionID string
+	api       string
+	op        string
+	start     time.Time
+	// operator serving the call, once known
+	operator string
+}
+
+// beginCall starts the span of op, returning a context for the work done by it
+func beginCall(ctx context.Context, sink types.MetricSink, settings types.GlideSdkSettings, api, op, sessionID string) (context.Context, *apiCall) {
+	ctx, span := utils.StartSpan(ctx, utils.Tracer(settings), op, trace.WithAttributes(attribute.String("glide.api", api)))
+	return ctx, &apiCall{span: span, sink: sink, clientID: settings.ClientID, sessionID: sessionID, api: api, op: op, start: time.Now()}
+}
+
+// funnel reports the funnel step name, e.g. "Glide start", unless the call has no session
+func (c *apiCall) funnel(name string) {
+	c.funnelFailure(name, "")
+}
+
+func (c *apiCall) funnelFailure(name, errorClass string) {
+	if c.sessionID == "" {
+		return
+	}
+	c.sink.Report(types.MetricInfo{
+		Operator:   c.operator,
+		Timestamp:  time.Now(),
+		SessionId:  c.sessionID,
+		MetricName: name,
+		Api:        c.api,
+		ClientId:   c.clientID,
+		ErrorClass: errorClass,
+	})
+}
+
+// setOperator takes the operator from the claims of session, if it has any
+func (c *apiCall) setOperator(session *types.Session) {
+	if operator, err := utils.GetOperator(session); err == nil {
+		c.operator = operator
+	}
+}
+
+func (c *apiCall) end(err error) {
+	outcome := utils.ErrorClass(err)
+	if err != nil {
+		c.funnelFailure("Glide failure", outcome)
+	}
+	c.sink.Report(types.MetricInfo{
+		Operator:   c.operator,
+		Timestamp:  c.start,
+		MetricName: c.op,
+		Api:        c.api,
+		ClientId:   c.clientID,
+		Outcome:    outcome,
+		Latency:    time.Since(c.start),
+	})
+	c.span.SetAttributes(attribute.String("glide.operator", c.operator), attribute.String("glide.outcome", outcome))
+	utils.EndSpan(c.span, err)
+}
@@ -1,13 +1,9 @@
 package services
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
-	"fmt"
-	"net/url"
-	"strings"
-	"sync"
-	"time"
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
 	"github.com/ClearBlockchain/sdk-go/pkg/types"
 	"github.com/ClearBlockchain/sdk-go/pkg/utils"
 )
@@ -24,26 +20,48 @@ type MagicAuthCheckResponse struct {
 }
 
 type MagicAuthClient struct {
-	settings types.GlideSdkSettings
-	session  *types.Session
+	settings  types.GlideSdkSettings
+	tokens    *auth.TokenManager
+	metrics   types.MetricSink
+	discovery *auth.Discovery
 }
 
-func NewMagicAuthClient(settings types.GlideSdkSettings) *MagicAuthClient {
+// NewMagicAuthClient creates a MagicAuthClient; opts share a session cache or
+// metric sink with other clients
+func NewMagicAuthClient(settings types.GlideSdkSettings, opts ...ClientOption) *MagicAuthClient {
+	o := newClientOptions(settings, opts)
 	return &MagicAuthClient{
-		settings: settings,
+		settings:  settings,
+		tokens:    o.tokens,
+		metrics:   o.metrics,
+		discovery: o.discovery,
 	}
 }
 
 func (c *MagicAuthClient) StartAuth(props types.MagicAuthStartProps, conf types.ApiConfig) (*MagicAuthStartResponse, error) {
-	var wg sync.WaitGroup
+	return c.StartAuthWithContext(context.Background(), props, conf)
+}
+
+// StartAuthWithContext is like StartAuth but aborts when ctx is cancelled
+func (c *MagicAuthClient) StartAuthWithContext(ctx context.Context, props types.MagicAuthStartProps, conf types.ApiConfig) (_ *MagicAuthStartResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "magic-auth", "magic-auth.start", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, fmt.Errorf("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
 	}
-	if conf.SessionIdentifier != "" {
-		c.reportMagicAuthMetric(&wg, conf.SessionIdentifier, "Glide start", "")
+	call.funnel("Glide start")
+
+	if props.PhoneNumber != "" {
+		phoneNumber, err := formatPhone(c.settings, props.PhoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		props.PhoneNumber = phoneNumber
 	}
 
-	session, err := c.getSession(conf.Session)
+	session, err := c.getSession(ctx, conf.Session)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +86,7 @@ func (c *MagicAuthClient) StartAuth(props types.MagicAuthStartProps, conf types.
 		return nil, err
 	}
 
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/magic-auth/verification/start", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/magic-auth/verification/start", utils.FetchXInput{
 		Method: "POST",
 		Headers: map[string]string{
 			"Content-Type":  "application/json",
@@ -78,18 +96,18 @@ func (c *MagicAuthClient) StartAuth(props types.MagicAuthStartProps, conf types.
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient]: [magic-auth] FetchX failed for startAuth : %w", err)
+		return nil, utils.WrapError("magic-auth.start", "[magic-auth] FetchX failed for startAuth", err)
 	}
 
 	var result MagicAuthStartResponse
 	if err := resp.JSON(&result); err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
+		return nil, utils.NewError("magic-auth.start", nil, "Failed to parse response", err)
 	}
 
-	if conf.SessionIdentifier != "" && result.OperatorId!="" {
-		c.reportMagicAuthMetric(&wg, conf.SessionIdentifier, "Glide verificationStartRes", result.OperatorId)
+	call.operator = result.OperatorId
+	if result.OperatorId!="" {
+		call.funnel("Glide verificationStartRes")
 	}
-	wg.Wait()
 	return &result, nil
 }
 
@@ -98,12 +116,28 @@ type MagicAuthVerifyRes struct {
 }
 
 func (c *MagicAuthClient) VerifyAuth(props types.MagicAuthVerifyProps, conf types.ApiConfig) (*MagicAuthVerifyRes, error) {
-	var wg sync.WaitGroup
+	return c.VerifyAuthWithContext(context.Background(), props, conf)
+}
+
+// VerifyAuthWithContext is like VerifyAuth but aborts when ctx is cancelled
+func (c *MagicAuthClient) VerifyAuthWithContext(ctx context.Context, props types.MagicAuthVerifyProps, conf types.ApiConfig) (_ *MagicAuthVerifyRes, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "magic-auth", "magic-auth.verify", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, fmt.Errorf("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
+	}
+
+	if props.PhoneNumber != "" {
+		phoneNumber, err := formatPhone(c.settings, props.PhoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		props.PhoneNumber = phoneNumber
 	}
 
-	session, err := c.getSession(conf.Session)
+	session, err := c.getSession(ctx, conf.Session)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +159,7 @@ func (c *MagicAuthClient) VerifyAuth(props types.MagicAuthVerifyProps, conf type
 		return nil, err
 	}
 
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/magic-auth/verification/check", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/magic-auth/verification/check", utils.FetchXInput{
 		Method: "POST",
 		Headers: map[string]string{
 			"Content-Type":  "application/json",
@@ -135,98 +169,36 @@ func (c *MagicAuthClient) VerifyAuth(props types.MagicAuthVerifyProps, conf type
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient]: [magic-auth] FetchX failed for VerifyAuth : %w", err)
+		return nil, utils.WrapError("magic-auth.verify", "[magic-auth] FetchX failed for VerifyAuth", err)
 	}
 
 	var result MagicAuthVerifyRes
 	if err := resp.JSON(&result); err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to parse response in VerifyAuth: %w", err)
+		return nil, utils.NewError("magic-auth.verify", nil, "Failed to parse response in VerifyAuth", err)
 	}
 
-	if conf.SessionIdentifier != "" {
-		c.reportMagicAuthMetric(&wg, conf.SessionIdentifier, "Glide success", "")
-		if result.Verified {
-			c.reportMagicAuthMetric(&wg, conf.SessionIdentifier, "Glide verified", "")
-		} else if !result.Verified {
-			c.reportMagicAuthMetric(&wg, conf.SessionIdentifier, "Glide unverified", "")
-	  }
+	call.funnel("Glide success")
+	if result.Verified {
+		call.funnel("Glide verified")
+	} else {
+		call.funnel("Glide unverified")
 	}
-	wg.Wait()
 	return &result, nil
 }
 
-func (c *MagicAuthClient) getSession(confSession *types.Session) (*types.Session, error) {
+func (c *MagicAuthClient) getSession(ctx context.Context, confSession *types.Session) (*types.Session, error) {
 	if confSession != nil {
 		return confSession, nil
 	}
 
-	if c.session != nil && c.session.ExpiresAt > time.Now().Add(time.Minute).Unix() && contains(c.session.Scopes, "magic-auth") {
-		fmt.Println("Debug: Using cached session")
-		return c.session, nil
-	}
-
-	session, err := c.generateNewSession()
-	if err != nil {
-		return nil, err
-	}
-
-	c.session = session
-	return session, nil
-}
-
-func (c *MagicAuthClient) generateNewSession() (*types.Session, error) {
-	if c.settings.ClientID == "" || c.settings.ClientSecret == "" {
-		return nil, fmt.Errorf("[GlideClient] Client credentials are required to generate a new session")
-	}
-
-	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
-	data.Set("scope", "magic-auth")
-
-	resp, err := utils.FetchX(c.settings.Internal.AuthBaseURL+"/oauth2/token", utils.FetchXInput{
-		Method: "POST",
-		Headers: map[string]string{
-			"Content-Type":  "application/x-www-form-urlencoded",
-			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(c.settings.ClientID+":"+c.settings.ClientSecret)),
-		},
-		Body: data.Encode(),
+	key := auth.TokenKey{GrantType: auth.GrantClientCredentials, Scope: "magic-auth"}
+	session, err := c.tokens.Get(ctx, key, func(ctx context.Context) (*types.Session, error) {
+		return auth.ClientCredentials(ctx, c.settings, c.discovery, "magic-auth")
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate new session: %w", err)
+		return nil, utils.WrapError("magic-auth.session", "Failed to generate new session", err)
 	}
-
-    var body struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int64  `json:"expires_in"`
-		Scope       string `json:"scope"`
-	}
-	if err := resp.JSON(&body); err != nil {
-		return nil, err
-	}
-
-	return &types.Session{
-		AccessToken: body.AccessToken,
-		ExpiresAt:   time.Now().Unix() + body.ExpiresIn,
-		Scopes:      strings.Split(body.Scope, " "),
-	}, nil
-}
-
-func (c *MagicAuthClient) reportMagicAuthMetric(wg *sync.WaitGroup, sessionId, metricName string, operator string) {
-	fmt.Println("in reportMagicAuthMetric", metricName)
-	metric := types.MetricInfo{
-		Operator:   operator,
-		Timestamp:  time.Now(),
-		SessionId:  sessionId,
-		MetricName: metricName,
-		Api:        "magic-auth",
-		ClientId:   c.settings.ClientID,
-	}
-	wg.Add(1)
-	go func(m types.MetricInfo) {
-		defer wg.Done()
-		utils.ReportMetric(m)
-	}(metric)
+	return session, nil
 }
 
 func (c *MagicAuthClient) GetHello() string {
@@ -1,97 +1,232 @@
 package services
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
 	"github.com/ClearBlockchain/sdk-go/pkg/types"
 	"github.com/ClearBlockchain/sdk-go/pkg/utils"
 	"github.com/google/uuid"
 )
 
+// refreshMargin is the remaining lifetime below which a session with a refresh token is renewed
+const refreshMargin = time.Minute
+
 type NumberVerifyUserClient struct {
     settings types.GlideSdkSettings
-	session  *types.Session
+	// mu guards session, which belongs to this client and those resuming it
+	mu         sync.Mutex
+	session    *types.Session
+	sessionKey string
 	code        string
+	codeVerifier string
+	nonce       string
 	phoneNumber *string
+	metrics     types.MetricSink
+	discovery   *auth.Discovery
+	verifier    *auth.IDTokenVerifier
+	idClaims    *types.IDTokenClaims
 }
 
-func NewNumberVerifyUserClient(settings types.GlideSdkSettings, params types.NumberVerifyClientForParams) *NumberVerifyUserClient {
+// NewNumberVerifyUserClient creates a NumberVerifyUserClient; opts share a
+// metric sink or discovery with other clients. Its session is never shared with
+// other subscribers; the session store keeps it under SessionKey.
+func NewNumberVerifyUserClient(settings types.GlideSdkSettings, params types.NumberVerifyClientForParams, opts ...ClientOption) *NumberVerifyUserClient {
+	o := newClientOptions(settings, opts)
 	return &NumberVerifyUserClient{
 		settings:    settings,
+		sessionKey:  uuid.New().String(),
 		code:        params.Code,
+		codeVerifier: params.CodeVerifier,
+		nonce:       params.Nonce,
 		phoneNumber: params.PhoneNumber,
+		metrics:     o.metrics,
+		discovery:   o.discovery,
+		verifier:    auth.NewIDTokenVerifier(settings, o.discovery),
 	}
 }
 
 func (c *NumberVerifyUserClient) StartSession() error {
+	return c.StartSessionWithContext(context.Background())
+}
+
+// StartSessionWithContext is like StartSession but aborts when ctx is cancelled
+func (c *NumberVerifyUserClient) StartSessionWithContext(ctx context.Context) (err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "number-verify", "number-verify.start-session", "")
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, types.ApiConfig{})
+	defer cancel()
 	if c.settings.Internal.AuthBaseURL == "" {
-		return errors.New("[GlideClient] internal.authBaseUrl is unset")
-	}
-	if c.settings.ClientID == "" || c.settings.ClientSecret == "" {
-		return errors.New("[GlideClient] Client credentials are required to generate a new session")
+		return &utils.ValidationError{Field: "Internal.AuthBaseURL", Message: "internal.authBaseUrl is unset"}
 	}
 	if c.code == "" {
-		return errors.New("[GlideClient] Code is required to start a session")
-	}
-	data := url.Values{}
-	data.Set("grant_type", "authorization_code")
-	data.Set("code", c.code)
-    resp, err := utils.FetchX(c.settings.Internal.AuthBaseURL+"/oauth2/token", utils.FetchXInput{
-        Method: "POST",
-        Headers: map[string]string{
-            "Content-Type":  "application/x-www-form-urlencoded",
-            "Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(c.settings.ClientID+":"+c.settings.ClientSecret)),
-        },
-        Body: data.Encode(),
-    })
+		return &utils.ValidationError{Field: "Code", Message: "Code is required to start a session"}
+	}
+	form := url.Values{
+		"grant_type": {auth.GrantAuthorizationCode},
+		"code":       {c.code},
+	}
+	if c.codeVerifier != "" {
+		form.Set("code_verifier", c.codeVerifier)
+	}
+	body, err := auth.RequestToken(ctx, c.settings, c.discovery, form)
 	if err != nil {
-		return fmt.Errorf("failed to generate new session: %w", err)
+		return utils.WrapError("number-verify.start-session", "Failed to generate new session", err)
 	}
-	var body struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int64    `json:"expires_in"`
-		Scope       string `json:"scope"`
+	if body.IDToken != "" {
+		claims, err := c.verifier.Verify(ctx, body.IDToken, c.nonce)
+		if err != nil {
+			return utils.WrapError("number-verify.start-session", "Failed to verify id_token", err)
+		}
+		c.idClaims = claims
 	}
+	session := body.Session()
+	c.mu.Lock()
+	c.session = session
+	c.mu.Unlock()
+	c.save(ctx, session)
+	call.setOperator(session)
+	return nil
+}
 
-	if err := resp.JSON(&body); err != nil {
-	        fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
-    		return nil
-    }
+// getSession returns the session of the code exchange, renewed with its refresh
+// token shortly before it expires; one without a refresh token is used until it expires
+func (c *NumberVerifyUserClient) getSession(ctx context.Context) (*types.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == nil {
+		return nil, &utils.InsufficientSessionError{Message: "[GlideClient] Session is required to verify a number"}
+	}
+	if fresh(c.session) {
+		return c.session, nil
+	}
+	// a client resuming the session elsewhere may have renewed it, rotating the refresh token
+	if stored := c.load(ctx); stored != nil && stored.ExpiresAt > c.session.ExpiresAt {
+		c.session = stored
+		if fresh(stored) {
+			return stored, nil
+		}
+	}
+	session, err := auth.RefreshSession(ctx, c.settings, c.discovery, c.session)
+	if err != nil {
+		return nil, err
+	}
+	c.session = session
+	c.save(ctx, session)
+	return session, nil
+}
 
-	c.session = &types.Session{
-		AccessToken: body.AccessToken,
-		ExpiresAt:   time.Now().Unix() + body.ExpiresIn,
-		Scopes:      strings.Split(body.Scope, " "),
+// fresh tells whether session can be used as it is: one with a refresh token
+// until refreshMargin before it expires, others until they expire
+func fresh(session *types.Session) bool {
+	now := time.Now()
+	if session.RefreshToken == "" {
+		return session.ExpiresAt > now.Unix()
 	}
-	return nil
+	return session.ExpiresAt > now.Add(refreshMargin).Unix()
+}
+
+// SessionKey identifies the session in the session store; NumberVerifyClient.Resume
+// continues it with that key, e.g. after a restart
+func (c *NumberVerifyUserClient) SessionKey() string {
+	return c.sessionKey
+}
+
+func numberVerifyStoreKey(clientID, sessionKey string) string {
+	return "glide|" + clientID + "|number-verify|" + sessionKey
+}
+
+// load reads the session from the session store, if there is one; a failed
+// read only costs a refresh
+func (c *NumberVerifyUserClient) load(ctx context.Context) *types.Session {
+	if c.settings.SessionStore == nil {
+		return nil
+	}
+	session, err := c.settings.SessionStore.Get(ctx, numberVerifyStoreKey(c.settings.ClientID, c.sessionKey))
+	if err != nil {
+		utils.Logger(c.settings).WarnContext(ctx, "reading session store failed", "error", err)
+	}
+	return session
+}
+
+// save keeps session in the session store, if there is one, so it can be
+// resumed; a failed write only loses that copy
+func (c *NumberVerifyUserClient) save(ctx context.Context, session *types.Session) {
+	if c.settings.SessionStore == nil {
+		return
+	}
+	if ttl := auth.SessionTTL(session); ttl > 0 {
+		if err := c.settings.SessionStore.Put(ctx, numberVerifyStoreKey(c.settings.ClientID, c.sessionKey), session, ttl); err != nil {
+			utils.Logger(c.settings).WarnContext(ctx, "writing session store failed", "error", err)
+		}
+	}
+}
+
+// Revoke revokes the refresh token of the session, which also ends its access
+// token, and forgets the session
+func (c *NumberVerifyUserClient) Revoke() error {
+	return c.RevokeWithContext(context.Background())
+}
+
+// RevokeWithContext is like Revoke but aborts when ctx is cancelled
+func (c *NumberVerifyUserClient) RevokeWithContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == nil {
+		return nil
+	}
+	if err := revoke(ctx, c.settings, c.discovery, c.session); err != nil {
+		return err
+	}
+	c.session = nil
+	if c.settings.SessionStore == nil {
+		return nil
+	}
+	return c.settings.SessionStore.Delete(ctx, numberVerifyStoreKey(c.settings.ClientID, c.sessionKey))
+}
+
+// Close revokes the session of the client and removes it from the session
+// store; the client must not be used afterwards
+func (c *NumberVerifyUserClient) Close(ctx context.Context) error {
+	return c.RevokeWithContext(ctx)
+}
+
+// IDTokenClaims returns the claims of the verified id_token of the session, or
+// nil if the authorization server did not return one
+func (c *NumberVerifyUserClient) IDTokenClaims() *types.IDTokenClaims {
+	return c.idClaims
 }
 
 func (c *NumberVerifyUserClient) GetOperator() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
     return utils.GetOperator(c.session)
 }
 
 func (c *NumberVerifyUserClient) VerifyNumber(number *string, conf types.ApiConfig) (*types.NumberVerifyResponse, error) {
-	var wg sync.WaitGroup
-	if conf.SessionIdentifier != "" {
-		operator, err := utils.GetOperator(c.session)
-		if err != nil {
-		    fmt.Errorf("cannot report metric since failed to get operator: %w", err)
-        }
-		c.reportNumberVerifyMetric(&wg, conf.SessionIdentifier, "Glide numberVerify start function", operator)
-	}
-	if c.session == nil {
-		return nil, errors.New("[GlideClient] Session is required to verify a number")
+	return c.VerifyNumberWithContext(context.Background(), number, conf)
+}
+
+// VerifyNumberWithContext is like VerifyNumber but aborts when ctx is cancelled
+func (c *NumberVerifyUserClient) VerifyNumberWithContext(ctx context.Context, number *string, conf types.ApiConfig) (_ *types.NumberVerifyResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "number-verify", "number-verify.verify", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
+	// the start step reports the operator of the session, so resolve it first
+	session, err := c.getSession(ctx)
+	call.setOperator(session)
+	call.funnel("Glide numberVerify start function")
+	if err != nil {
+		return nil, err
 	}
 
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, errors.New("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
 	}
 
 	var phoneNumber string
@@ -100,58 +235,84 @@ func (c *NumberVerifyUserClient) VerifyNumber(number *string, conf types.ApiConf
 	} else if c.phoneNumber != nil {
 		phoneNumber = *c.phoneNumber
 	} else {
-		return nil, errors.New("[GlideClient] Phone number is required to verify a number")
+		return nil, &utils.ValidationError{Field: "PhoneNumber", Message: "Phone number is required to verify a number"}
 	}
 
-	body, err := json.Marshal(map[string]string{"phoneNumber": utils.FormatPhoneNumber(phoneNumber)})
+	phoneNumber, err = formatPhone(c.settings, phoneNumber)
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient] failed to marshal payload in number verify: %w", err)
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{"phoneNumber": phoneNumber})
+	if err != nil {
+		return nil, utils.NewError("number-verify.verify", nil, "failed to marshal payload in number verify", err)
 	}
 
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/number-verification/verify", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/number-verification/verify", utils.FetchXInput{
     		Method: "POST",
+    		Idempotent: true,
     		Headers: map[string]string{
     			"Content-Type":  "application/json",
-    			"Authorization": "Bearer " + c.session.AccessToken,
+    			"Authorization": "Bearer " + session.AccessToken,
     		},
     		Body: string(body),
     })
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify number: %w", err)
+		return nil, utils.WrapError("number-verify.verify", "failed to verify number", err)
 	}
 
 	var result types.NumberVerifyResponse
 	if err := resp.JSON(&result); err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
-	}
-	// Metric reporting for success/failure
-    if conf.SessionIdentifier != "" {
-        c.reportNumberVerifyMetric(&wg, conf.SessionIdentifier, "Glide success", "")
-        if result.DevicePhoneNumberVerified {
-            c.reportNumberVerifyMetric(&wg, conf.SessionIdentifier, "Glide verified", "")
-        } else {
-            c.reportNumberVerifyMetric(&wg, conf.SessionIdentifier, "Glide unverified", "")
-        }
-    }
-	wg.Wait()
+		return nil, utils.NewError("number-verify.verify", nil, "Failed to parse response", err)
+	}
+	call.funnel("Glide success")
+	if result.DevicePhoneNumberVerified {
+		call.funnel("Glide verified")
+	} else {
+		call.funnel("Glide unverified")
+	}
 	return &result, nil
 }
 
 type NumberVerifyClient struct {
 	settings types.GlideSdkSettings
+	metrics  types.MetricSink
+	discovery *auth.Discovery
+	states   types.AuthStateStore
+	verifier *auth.IDTokenVerifier
+}
+
+// NewNumberVerifyClient creates a NumberVerifyClient; opts share a metric sink
+// or discovery with other clients, and its user clients share them too
+func NewNumberVerifyClient(settings types.GlideSdkSettings, opts ...ClientOption) *NumberVerifyClient {
+	o := newClientOptions(settings, opts)
+	states := settings.AuthStateStore
+	if states == nil {
+		states = auth.NewMemoryAuthStateStore()
+	}
+	return &NumberVerifyClient{
+		settings: settings,
+		metrics:  o.metrics,
+		discovery: o.discovery,
+		states:   states,
+		verifier: auth.NewIDTokenVerifier(settings, o.discovery),
+	}
 }
 
-func NewNumberVerifyClient(settings types.GlideSdkSettings) *NumberVerifyClient {
-	return &NumberVerifyClient{settings: settings}
+// GetAuthURL builds the URL to open on the user's device, with a PKCE challenge,
+// and remembers its state until the callback
+func (c *NumberVerifyClient) GetAuthURL(opts ...types.NumberVerifyAuthUrlInput) (*types.NumberVerifyAuthURL, error) {
+	return c.GetAuthURLWithContext(context.Background(), opts...)
 }
 
-func (c *NumberVerifyClient) GetAuthURL(opts ...types.NumberVerifyAuthUrlInput) (string, error) {
+// GetAuthURLWithContext is like GetAuthURL but aborts storing the state when ctx is cancelled
+func (c *NumberVerifyClient) GetAuthURLWithContext(ctx context.Context, opts ...types.NumberVerifyAuthUrlInput) (*types.NumberVerifyAuthURL, error) {
 	if c.settings.Internal.AuthBaseURL == "" {
-		return "", errors.New("[GlideClient] internal.authBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.AuthBaseURL", Message: "internal.authBaseUrl is unset"}
 	}
 	if c.settings.ClientID == "" {
-		return "", errors.New("[GlideClient] Client id is required to generate an auth url")
+		return nil, &utils.ValidationError{Field: "ClientID", Message: "Client id is required to generate an auth url"}
 	}
 	var state string
     if len(opts) > 0 && opts[0].State != nil {
@@ -160,6 +321,10 @@ func (c *NumberVerifyClient) GetAuthURL(opts ...types.NumberVerifyAuthUrlInput)
         state = uuid.New().String()
     }
 	nonce := uuid.New().String()
+	pkce, err := auth.NewPKCE()
+	if err != nil {
+		return nil, utils.NewError("number-verify.auth-url", nil, "Failed to generate PKCE verifier", err)
+	}
 	params := url.Values{}
 	params.Set("client_id", c.settings.ClientID)
 	params.Set("response_type", "code")
@@ -171,38 +336,116 @@ func (c *NumberVerifyClient) GetAuthURL(opts ...types.NumberVerifyAuthUrlInput)
 	params.Set("state", state)
 	params.Set("nonce", nonce)
 	params.Set("max_age", "0")
+	params.Set("code_challenge", pkce.Challenge)
+	params.Set("code_challenge_method", pkce.Method)
 	if len(opts) > 0 && opts[0].UseDevNumber != "" {
 		params.Set("login_hint", "tel:"+opts[0].UseDevNumber)
 	}
 	if len(opts) > 0 && opts[0].PrintCode {
 		params.Set("dev_print", "true")
 	}
-	return c.settings.Internal.AuthBaseURL + "/oauth2/auth?" + params.Encode(), nil
+
+	pending := &types.AuthState{Nonce: nonce, CodeVerifier: pkce.Verifier}
+	if err := c.states.Put(ctx, state, pending, auth.DefaultAuthStateTTL); err != nil {
+		return nil, utils.NewError("number-verify.auth-url", nil, "Failed to store auth state", err)
+	}
+	return &types.NumberVerifyAuthURL{
+		URL:          c.discovery.Endpoints(ctx).Authorization + "?" + params.Encode(),
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: pkce.Verifier,
+		ExpiresAt:    time.Now().Add(auth.DefaultAuthStateTTL),
+	}, nil
+}
+
+// ParseCallback checks the query of the redirect back from an auth URL. The
+// state must be one GetAuthURL issued and not used yet; an error reported by
+// the authorization server is returned classified like token endpoint errors.
+// The result carries the code, verifier and nonce for For.
+func (c *NumberVerifyClient) ParseCallback(ctx context.Context, query url.Values) (*types.NumberVerifyClientForParams, error) {
+	state := query.Get("state")
+	if state == "" {
+		return nil, &utils.ValidationError{Field: "State", Message: "state is missing from the callback"}
+	}
+	pending, err := c.states.Take(ctx, state)
+	if err != nil {
+		return nil, utils.NewError("number-verify.callback", nil, "Failed to load auth state", err)
+	}
+	if pending == nil {
+		return nil, &utils.ValidationError{Field: "State", Message: "state is unknown, expired or already used"}
+	}
+	if code := query.Get("error"); code != "" {
+		message := "Authorization failed with " + code
+		if description := query.Get("error_description"); description != "" {
+			message += ": " + description
+		}
+		return nil, utils.NewError("number-verify.callback", utils.ClassifyOAuthError(code), message, nil)
+	}
+	if query.Get("code") == "" {
+		return nil, &utils.ValidationError{Field: "Code", Message: "code is missing from the callback"}
+	}
+	return &types.NumberVerifyClientForParams{Code: query.Get("code"), CodeVerifier: pending.CodeVerifier, Nonce: pending.Nonce}, nil
+}
+
+// ForCallback validates the callback query with ParseCallback and only then
+// exchanges its code for a user client verifying phoneNumber
+func (c *NumberVerifyClient) ForCallback(query url.Values, phoneNumber *string) (*NumberVerifyUserClient, error) {
+	return c.ForCallbackWithContext(context.Background(), query, phoneNumber)
+}
+
+// ForCallbackWithContext is like ForCallback but aborts when ctx is cancelled
+func (c *NumberVerifyClient) ForCallbackWithContext(ctx context.Context, query url.Values, phoneNumber *string) (*NumberVerifyUserClient, error) {
+	params, err := c.ParseCallback(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	params.PhoneNumber = phoneNumber
+	return c.ForWithContext(ctx, *params)
 }
 
 func (c *NumberVerifyClient) For(params types.NumberVerifyClientForParams) (*NumberVerifyUserClient, error) {
-	client := NewNumberVerifyUserClient(c.settings, params)
-	err := client.StartSession()
+	return c.ForWithContext(context.Background(), params)
+}
+
+// ForWithContext is like For but aborts the code exchange when ctx is cancelled
+func (c *NumberVerifyClient) ForWithContext(ctx context.Context, params types.NumberVerifyClientForParams) (*NumberVerifyUserClient, error) {
+	client := NewNumberVerifyUserClient(c.settings, params, WithMetricSink(c.metrics), WithDiscovery(c.discovery))
+	// share the cached signing keys
+	client.verifier = c.verifier
+	err := client.StartSessionWithContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return client, nil
 }
 
-func (c *NumberVerifyUserClient) reportNumberVerifyMetric(wg *sync.WaitGroup, sessionId, metricName string, operator string) {
-	metric := types.MetricInfo{
-		Operator:   operator,
-		Timestamp:  time.Now(),
-		SessionId:  sessionId,
-		MetricName: metricName,
-		Api:        "number-verify",
-		ClientId:   c.settings.ClientID,
-	}
-	wg.Add(1)
-	go func(m types.MetricInfo) {
-		defer wg.Done()
-		utils.ReportMetric(m)
-	}(metric)
+// Resume rebuilds the user client of the session stored under sessionKey, see
+// NumberVerifyUserClient.SessionKey, to verify phoneNumber. It needs the session
+// store of the client that started the session; the id_token claims are not kept.
+func (c *NumberVerifyClient) Resume(sessionKey string, phoneNumber *string) (*NumberVerifyUserClient, error) {
+	return c.ResumeWithContext(context.Background(), sessionKey, phoneNumber)
+}
+
+// ResumeWithContext is like Resume but aborts reading the session store when ctx is cancelled
+func (c *NumberVerifyClient) ResumeWithContext(ctx context.Context, sessionKey string, phoneNumber *string) (*NumberVerifyUserClient, error) {
+	if c.settings.SessionStore == nil {
+		return nil, &utils.ValidationError{Field: "SessionStore", Message: "a session store is required to resume a session"}
+	}
+	if sessionKey == "" {
+		return nil, &utils.ValidationError{Field: "SessionKey", Message: "session key is required to resume a session"}
+	}
+	session, err := c.settings.SessionStore.Get(ctx, numberVerifyStoreKey(c.settings.ClientID, sessionKey))
+	if err != nil {
+		return nil, utils.NewError("number-verify.resume", nil, "Failed to read session store", err)
+	}
+	if session == nil {
+		return nil, &utils.InsufficientSessionError{Message: "[GlideClient] Session is unknown, expired or revoked"}
+	}
+	client := NewNumberVerifyUserClient(c.settings, types.NumberVerifyClientForParams{PhoneNumber: phoneNumber}, WithMetricSink(c.metrics), WithDiscovery(c.discovery))
+	client.verifier = c.verifier
+	client.sessionKey = sessionKey
+	client.session = session
+	return client, nil
 }
 
 func (c *NumberVerifyClient) GetHello() (string) {
@@ -1,13 +1,16 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
 	"github.com/ClearBlockchain/sdk-go/pkg/types"
 	"github.com/ClearBlockchain/sdk-go/pkg/utils"
-	"encoding/base64"
+    "net/http"
     "net/url"
-    "strings"
+    "sync"
     "time"
 )
 
@@ -24,52 +27,99 @@ type SimSwapRetrieveDateResponse struct {
 type SimSwapUserClient struct {
 	settings         types.GlideSdkSettings
 	identifier       types.UserIdentifier
-	session          *types.Session
+	tokens           *auth.TokenManager
+	metrics          types.MetricSink
+	discovery        *auth.Discovery
 	RequiresConsent  bool
+
+	mu               sync.Mutex
 	consentURL       string
-	authReqID        string
+	authReq          cibaRequest
+	notifications    *auth.NotificationHandler
+}
+
+// cibaRequest is a pending backchannel authentication request
+type cibaRequest struct {
+	id        string
+	interval  time.Duration
+	expiresAt time.Time
+	// set in ping and push mode, where the operator calls back instead of being polled
+	notifyToken string
+	waiter      *auth.NotificationWaiter
+}
+
+// notified is closed when the operator called back, nil in poll mode
+func (r cibaRequest) notified() <-chan struct{} {
+	if r.waiter == nil {
+		return nil
+	}
+	return r.waiter.Done()
 }
 
-func NewSimSwapUserClient(settings types.GlideSdkSettings, identifier types.UserIdentifier) *SimSwapUserClient {
+// NewSimSwapUserClient creates a SimSwapUserClient; opts share a session cache
+// or metric sink with other clients
+func NewSimSwapUserClient(settings types.GlideSdkSettings, identifier types.UserIdentifier, opts ...ClientOption) *SimSwapUserClient {
+	o := newClientOptions(settings, opts)
 	return &SimSwapUserClient{
 		settings:   settings,
 		identifier: identifier,
+		tokens:     o.tokens,
+		metrics:    o.metrics,
+		discovery:  o.discovery,
 	}
 }
 
 func (c *SimSwapUserClient) GetConsentURL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.consentURL
 }
 
 // Check performs a SIM swap check
 func (c *SimSwapUserClient) Check(params types.SimSwapCheckParams, conf types.ApiConfig) (*SimSwapCheckResponse, error) {
+	return c.CheckWithContext(context.Background(), params, conf)
+}
+
+// CheckWithContext is like Check but aborts when ctx is cancelled
+func (c *SimSwapUserClient) CheckWithContext(ctx context.Context, params types.SimSwapCheckParams, conf types.ApiConfig) (_ *SimSwapCheckResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "sim-swap", "sim-swap.check", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, fmt.Errorf("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
 	}
+	call.funnel("Glide start")
 	phoneNumber := params.PhoneNumber
 	if phoneNumber == "" {
 		if phoneIdentifier, ok := c.identifier.(types.PhoneIdentifier); ok {
 			phoneNumber = phoneIdentifier.PhoneNumber
 		} else {
-			return nil, fmt.Errorf("[GlideClient] phone number not provided")
+			return nil, &utils.ValidationError{Field: "PhoneNumber", Message: "phone number not provided"}
 		}
 	}
-	session, err := c.getSession(conf.Session)
+	phoneNumber, err = formatPhone(c.settings, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.getSession(ctx, conf.Session)
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to get session: %w", err)
+		return nil, utils.WrapError("sim-swap.check", "Failed to get session", err)
 	}
+	call.setOperator(session)
 	body := map[string]interface{}{
-		"phoneNumber": utils.FormatPhoneNumber(phoneNumber),
+		"phoneNumber": phoneNumber,
 	}
 	if params.MaxAge != nil {
 		body["maxAge"] = *params.MaxAge
 	}
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to marshal request body: %w", err)
+		return nil, utils.NewError("sim-swap.check", nil, "Failed to marshal request body", err)
 	}
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/sim-swap/check", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/sim-swap/check", utils.FetchXInput{
 		Method: "POST",
+		Idempotent: true,
 		Headers: map[string]string{
 			"Content-Type":  "application/json",
 			"Authorization": "Bearer " + session.AccessToken,
@@ -77,14 +127,20 @@ func (c *SimSwapUserClient) Check(params types.SimSwapCheckParams, conf types.Ap
 		Body: string(bodyJSON),
 	})
 	if err != nil {
-		if fetchErr, ok := err.(*utils.FetchError); ok && fetchErr.Response.StatusCode == 404 {
-			return nil, fmt.Errorf("[GlideClient] Network ID not found for number %s", phoneNumber)
+		if fetchErr, ok := utils.AsFetchError(err); ok && fetchErr.Status() == 404 {
+			return nil, utils.NewError("sim-swap.check", utils.ErrNumberNotSupported, fmt.Sprintf("Network ID not found for number %s", phoneNumber), err)
 		}
-		return nil, fmt.Errorf("[GlideClient] FetchX failed: %w", err)
+		return nil, utils.WrapError("sim-swap.check", "FetchX failed", err)
 	}
 	var result SimSwapCheckResponse
 	if err := resp.JSON(&result); err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
+		return nil, utils.NewError("sim-swap.check", nil, "Failed to parse response", err)
+	}
+	call.funnel("Glide success")
+	if result.Swapped {
+		call.funnel("Glide swapped")
+	} else {
+		call.funnel("Glide not swapped")
 	}
 	return &result, nil
 }
@@ -92,35 +148,51 @@ func (c *SimSwapUserClient) Check(params types.SimSwapCheckParams, conf types.Ap
 
 // RetrieveDate retrieves the date of the latest SIM swap
 func (c *SimSwapUserClient) RetrieveDate(params types.SimSwapRetrieveDateParams, conf types.ApiConfig) (*SimSwapRetrieveDateResponse, error) {
+	return c.RetrieveDateWithContext(context.Background(), params, conf)
+}
+
+// RetrieveDateWithContext is like RetrieveDate but aborts when ctx is cancelled
+func (c *SimSwapUserClient) RetrieveDateWithContext(ctx context.Context, params types.SimSwapRetrieveDateParams, conf types.ApiConfig) (_ *SimSwapRetrieveDateResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "sim-swap", "sim-swap.retrieve-date", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, fmt.Errorf("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
 	}
+	call.funnel("Glide start")
 
 	phoneNumber := params.PhoneNumber
 	if phoneNumber == "" {
 		if phoneIdentifier, ok := c.identifier.(types.PhoneIdentifier); ok {
 			phoneNumber = phoneIdentifier.PhoneNumber
 		} else {
-			return nil, fmt.Errorf("[GlideClient] phone number not provided")
+			return nil, &utils.ValidationError{Field: "PhoneNumber", Message: "phone number not provided"}
 		}
 	}
+	phoneNumber, err = formatPhone(c.settings, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
 
-	session, err := c.getSession(conf.Session)
+	session, err := c.getSession(ctx, conf.Session)
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to get session: %w", err)
+		return nil, utils.WrapError("sim-swap.retrieve-date", "Failed to get session", err)
 	}
+	call.setOperator(session)
 
 	body := map[string]string{
-		"phoneNumber": utils.FormatPhoneNumber(phoneNumber),
+		"phoneNumber": phoneNumber,
 	}
 
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to marshal request body: %w", err)
+		return nil, utils.NewError("sim-swap.retrieve-date", nil, "Failed to marshal request body", err)
 	}
 
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/sim-swap/retrieve-date", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/sim-swap/retrieve-date", utils.FetchXInput{
 		Method: "POST",
+		Idempotent: true,
 		Headers: map[string]string{
 			"Content-Type":  "application/json",
 			"Authorization": "Bearer " + session.AccessToken,
@@ -129,164 +201,368 @@ func (c *SimSwapUserClient) RetrieveDate(params types.SimSwapRetrieveDateParams,
 	})
 
 	if err != nil {
-		if fetchErr, ok := err.(*utils.FetchError); ok && fetchErr.Response.StatusCode == 404 {
-			return nil, fmt.Errorf("[GlideClient] Network ID not found for number %s", phoneNumber)
+		if fetchErr, ok := utils.AsFetchError(err); ok && fetchErr.Status() == 404 {
+			return nil, utils.NewError("sim-swap.retrieve-date", utils.ErrNumberNotSupported, fmt.Sprintf("Network ID not found for number %s", phoneNumber), err)
 		}
-		return nil, fmt.Errorf("[GlideClient] FetchX failed: %w", err)
+		return nil, utils.WrapError("sim-swap.retrieve-date", "FetchX failed", err)
 	}
 
 	var result SimSwapRetrieveDateResponse
 	if err := resp.JSON(&result); err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
+		return nil, utils.NewError("sim-swap.retrieve-date", nil, "Failed to parse response", err)
 	}
+	call.funnel("Glide success")
 
 	return &result, nil
 }
 
 func (c *SimSwapUserClient) StartSession() error {
-	if c.settings.ClientID == "" || c.settings.ClientSecret == "" {
-		return fmt.Errorf("[GlideClient] Client credentials are required to generate a new session")
+	return c.StartSessionWithContext(context.Background())
+}
+
+// StartSessionWithContext is like StartSession but aborts when ctx is cancelled
+func (c *SimSwapUserClient) StartSessionWithContext(ctx context.Context) (err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "sim-swap", "sim-swap.start-session", "")
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, types.ApiConfig{})
+	defer cancel()
+	if err := auth.ValidateClientAuth(c.settings); err != nil {
+		return err
 	}
-	var loginHint string
-	switch identifier := c.identifier.(type) {
-	case types.PhoneIdentifier:
-		loginHint = "tel:" + utils.FormatPhoneNumber(identifier.PhoneNumber)
-	case types.IpIdentifier:
-		loginHint = "ipport:" + identifier.IPAddress
+	loginHint, err := c.loginHint()
+	if err != nil {
+		return err
 	}
 	data := url.Values{}
 	data.Set("scope", "sim-swap")
 	if loginHint != "" {
 		data.Set("login_hint", loginHint)
 	}
-	resp, err := utils.FetchX(c.settings.Internal.AuthBaseURL+"/oauth2/backchannel-authentication", utils.FetchXInput{
-		Method: "POST",
-		Headers: map[string]string{
-			"Content-Type":  "application/x-www-form-urlencoded",
-			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(c.settings.ClientID+":"+c.settings.ClientSecret)),
-		},
-		Body: data.Encode(),
+	var req cibaRequest
+	switch mode := c.settings.CIBADeliveryMode; mode {
+	case "", types.CIBAPoll:
+	case types.CIBAPing, types.CIBAPush:
+		if c.notifications == nil {
+			return &utils.ValidationError{Field: "CIBADeliveryMode", Message: fmt.Sprintf("%s mode needs a user client created by SimSwapClient.For", mode)}
+		}
+		req.notifyToken, err = auth.NewNotificationToken()
+		if err != nil {
+			return utils.NewError("sim-swap.start-session", nil, "Failed to generate client notification token", err)
+		}
+		data.Set("client_notification_token", req.notifyToken)
+		// register before sending, the callback may beat the response
+		req.waiter = c.notifications.Register(req.notifyToken)
+		defer func() {
+			if err != nil {
+				c.notifications.Unregister(req.notifyToken)
+			}
+		}()
+	default:
+		return &utils.ValidationError{Field: "CIBADeliveryMode", Message: fmt.Sprintf("unknown delivery mode %q", mode)}
+	}
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	if err := auth.AuthenticateClient(ctx, c.settings, c.discovery, headers, data); err != nil {
+		return err
+	}
+	endpoint := c.discovery.Endpoints(ctx).BackchannelAuthentication
+	resp, err := fetch(ctx, c.settings, endpoint, utils.FetchXInput{
+		Method:  "POST",
+		Headers: headers,
+		Body:    data.Encode(),
 	})
 	if err != nil {
-		return fmt.Errorf("[GlideClient] FetchX failed: %w", err)
-	}
-	var body struct {
-		ConsentURL string `json:"consentUrl"`
-		AuthReqID  string `json:"auth_req_id"`
+		return utils.WrapError("sim-swap.start-session", "FetchX failed", err)
 	}
+	var body auth.BackchannelResponse
 	if err := resp.JSON(&body); err != nil {
-		return fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
+		return utils.NewError("sim-swap.start-session", nil, "Failed to parse response", err)
+	}
+	req.id, req.interval, req.expiresAt = body.AuthReqID, body.PollInterval(), body.ExpiresAt()
+	if req.waiter != nil {
+		req.waiter.SetAuthReqID(body.AuthReqID)
 	}
+	c.mu.Lock()
 	if body.ConsentURL != "" {
 		c.RequiresConsent = true
 		c.consentURL = body.ConsentURL
 	}
-	c.authReqID = body.AuthReqID
+	c.mu.Unlock()
+	c.setPendingRequest(req)
 
 	return nil
 }
 
 
-func (c *SimSwapUserClient) getSession(confSession *types.Session) (*types.Session, error) {
-    if confSession != nil {
-        fmt.Println("Debug: Using provided session")
-        return confSession, nil
-    }
-
-    if c.session != nil && c.session.ExpiresAt > time.Now().Add(time.Minute).Unix() && contains(c.session.Scopes, "sim-swap") {
-        fmt.Println("Debug: Using cached session")
-        return c.session, nil
-    }
-
-    fmt.Println("Debug: Generating new session")
-    session, err := c.generateNewSession()
-    if err != nil {
-        return nil, fmt.Errorf("failed to generate new session: %w", err)
-    }
-    c.authReqID = ""
-    c.session = session
-    return session, nil
+func (c *SimSwapUserClient) getSession(ctx context.Context, confSession *types.Session) (*types.Session, error) {
+	if confSession != nil {
+		return confSession, nil
+	}
+
+	key, err := c.tokenKey()
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.tokens.Get(ctx, key, c.generateNewSession)
+	if err != nil {
+		// while the user has not answered yet, point to where they consent
+		if consentURL := c.GetConsentURL(); consentURL != "" && errors.Is(err, utils.ErrConsentRequired) {
+			return nil, utils.NewError("sim-swap.session", utils.ErrConsentRequired, "User consent is required at "+consentURL, err)
+		}
+		return nil, utils.WrapError("sim-swap.session", "Failed to generate new session", err)
+	}
+	return session, nil
 }
 
 // PollAndWaitForSession continuously polls for a valid session
 func (c *SimSwapUserClient) PollAndWaitForSession() error {
-	for {
-		_, err := c.getSession(nil)
-		if err == nil {
-			return nil
+	return c.PollAndWaitForSessionWithContext(context.Background())
+}
+
+// PollAndWaitForSessionWithContext is PollForSession without progress reports
+func (c *SimSwapUserClient) PollAndWaitForSessionWithContext(ctx context.Context) error {
+	return c.PollForSession(ctx, nil)
+}
+
+// PollForSession polls the token endpoint until the user approves the
+// authentication request, starting one if none is pending. Polls are spaced by
+// the interval the server asked for, 5 seconds longer after every slow_down.
+// In ping and push mode it waits for the operator's callback instead. It stops
+// with an error matching utils.ErrAccessDenied when the user declines,
+// utils.ErrAuthExpired when the request expires first, or ctx's error when ctx
+// is done. A rate limited or unavailable token endpoint stops it with
+// utils.ErrRateLimited or utils.ErrUpstreamUnavailable; the request is kept, so
+// polling again later resumes it at a longer interval without asking the user
+// again. onProgress, if not nil, is called after every poll still pending.
+func (c *SimSwapUserClient) PollForSession(ctx context.Context, onProgress func(types.CIBAPollProgress)) (err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "sim-swap", "sim-swap.poll-session", "")
+	defer func() { call.end(err) }()
+	for attempt := 1; ; attempt++ {
+		_, err := c.getSession(ctx, nil)
+		if err == nil || !errors.Is(err, utils.ErrConsentRequired) {
+			return err
+		}
+		status := auth.ErrorAuthorizationPending
+		if fetchErr, ok := utils.AsFetchError(err); ok && fetchErr.OAuthError() == auth.ErrorSlowDown {
+			status = auth.ErrorSlowDown
+		}
+		req := c.pendingRequest()
+		notified := req.notified()
+		wait := req.interval
+		if wait == 0 {
+			wait = auth.DefaultPollInterval
+		}
+		expired := !req.expiresAt.IsZero() && time.Now().Add(wait).After(req.expiresAt)
+		if notified != nil {
+			// nothing to poll, wait for the callback until the request expires,
+			// or for as long as it takes when its lifetime is unknown
+			wait, expired = 0, false
+			if !req.expiresAt.IsZero() {
+				wait = time.Until(req.expiresAt)
+				expired = wait <= 0
+			}
+		}
+		if expired {
+			c.setPendingRequest(cibaRequest{})
+			return utils.NewError("sim-swap.poll-session", utils.ErrAuthExpired, "Authentication request expired before the user approved it", err)
+		}
+		if onProgress != nil {
+			onProgress(types.CIBAPollProgress{
+				Attempt:    attempt,
+				Status:     status,
+				Interval:   wait,
+				ExpiresAt:  req.expiresAt,
+				ConsentURL: c.GetConsentURL(),
+			})
+		}
+		timer := time.NewTimer(wait)
+		timeout := timer.C
+		if notified != nil && req.expiresAt.IsZero() {
+			// no expiry given, only the callback or ctx ends the wait
+			timer.Stop()
+			timeout = nil
+		}
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-notified:
+			timer.Stop()
+		case <-timeout:
 		}
-		time.Sleep(5 * time.Second)
 	}
 }
 
-// generateNewSession generates a new session
-func (c *SimSwapUserClient) generateNewSession() (*types.Session, error) {
-	if c.settings.ClientID == "" || c.settings.ClientSecret == "" {
-		return nil, fmt.Errorf("[GlideClient] Client credentials are required to generate a new session")
+// generateNewSession exchanges the pending auth request for a session, starting one if needed
+func (c *SimSwapUserClient) generateNewSession(ctx context.Context) (*types.Session, error) {
+	if err := auth.ValidateClientAuth(c.settings); err != nil {
+		return nil, err
 	}
 
-	if c.authReqID == "" {
-		if err := c.StartSession(); err != nil {
+	req := c.pendingRequest()
+	if req.id == "" {
+		if err := c.StartSessionWithContext(ctx); err != nil {
 			return nil, err
 		}
+		req = c.pendingRequest()
 	}
 
-	if c.authReqID == "" {
-		return nil, fmt.Errorf("[GlideClient] Failed to start session")
+	if req.id == "" {
+		return nil, utils.NewError("sim-swap.session", nil, "Failed to start session", nil)
 	}
 
-	data := url.Values{}
-	data.Set("grant_type", "urn:openid:params:grant-type:ciba")
-	data.Set("auth_req_id", c.authReqID)
+	if req.waiter != nil {
+		notification := req.waiter.Notification()
+		switch {
+		case notification == nil:
+			return nil, utils.NewError("sim-swap.session", utils.ErrConsentRequired, "Waiting for the operator to notify that the user answered", nil)
+		case notification.Error != "":
+			c.setPendingRequest(cibaRequest{})
+			return nil, notificationError(notification)
+		case notification.AccessToken != "":
+			// push mode delivered the token itself
+			c.setPendingRequest(cibaRequest{})
+			return notification.Session(), nil
+		}
+		// ping mode, the token is ready at the token endpoint
+	}
 
-	resp, err := utils.FetchX(c.settings.Internal.AuthBaseURL+"/oauth2/token", utils.FetchXInput{
-		Method: "POST",
-		Headers: map[string]string{
-			"Content-Type":  "application/x-www-form-urlencoded",
-			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(c.settings.ClientID+":"+c.settings.ClientSecret)),
-		},
-		Body: data.Encode(),
+	body, err := auth.RequestToken(ctx, c.settings, c.discovery, url.Values{
+		"grant_type":  {auth.GrantCIBA},
+		"auth_req_id": {req.id},
 	})
+	if err != nil {
+		fetchErr, ok := utils.AsFetchError(err)
+		switch {
+		case ok && fetchErr.OAuthError() == auth.ErrorAuthorizationPending:
+		case ok && fetchErr.OAuthError() == auth.ErrorSlowDown,
+			errors.Is(err, utils.ErrRateLimited), errors.Is(err, utils.ErrUpstreamUnavailable):
+			// the user may still answer the request, so keep it and poll less often
+			c.slowDown(req.id)
+		default:
+			// denied, expired or rejected, the next attempt starts a new request
+			c.setPendingRequest(cibaRequest{})
+		}
+		return nil, err
+	}
+	c.setPendingRequest(cibaRequest{})
+	return body.Session(), nil
+}
 
+// Close abandons any pending authentication request and revokes the session of
+// the subscriber; the client must not be used afterwards
+func (c *SimSwapUserClient) Close(ctx context.Context) error {
+	c.setPendingRequest(cibaRequest{})
+	key, err := c.tokenKey()
 	if err != nil {
-		c.authReqID = ""
-		return nil, fmt.Errorf("[GlideClient] FetchX failed: %w", err)
+		return err
 	}
+	return revokeSession(ctx, c.settings, c.discovery, c.tokens, key)
+}
+
+func (c *SimSwapUserClient) pendingRequest() cibaRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authReq
+}
 
-	var body struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int64    `json:"expires_in"`
-		Scope       string `json:"scope"`
+func (c *SimSwapUserClient) setPendingRequest(req cibaRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old := c.authReq.notifyToken; old != "" && old != req.notifyToken {
+		c.notifications.Unregister(old)
 	}
-	if err := resp.JSON(&body); err != nil {
-		return nil, fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
+	c.authReq = req
+}
+
+// notificationError maps the error pushed by the operator like the token endpoint's
+func notificationError(notification *auth.Notification) error {
+	message := "Operator notified error " + notification.Error
+	if notification.ErrorDescription != "" {
+		message += ": " + notification.ErrorDescription
+	}
+	return utils.NewError("sim-swap.session", utils.ClassifyOAuthError(notification.Error), message, nil)
+}
+
+// slowDown lengthens the poll interval of the request id, unless it was replaced meanwhile
+func (c *SimSwapUserClient) slowDown(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.authReq.id == id {
+		c.authReq.interval += auth.SlowDownIncrement
 	}
+}
 
-	return &types.Session{
-		AccessToken: body.AccessToken,
-		ExpiresAt:   time.Now().Unix() + body.ExpiresIn,
-		// ExpiresAt:   time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
-		Scopes:      strings.Split(body.Scope, " "),
-	}, nil
+func (c *SimSwapUserClient) tokenKey() (auth.TokenKey, error) {
+	loginHint, err := c.loginHint()
+	if err != nil {
+		return auth.TokenKey{}, err
+	}
+	return auth.TokenKey{GrantType: auth.GrantCIBA, Scope: "sim-swap", LoginHint: loginHint}, nil
+}
+
+// loginHint identifies the subscriber to the authorization server
+func (c *SimSwapUserClient) loginHint() (string, error) {
+	switch identifier := c.identifier.(type) {
+	case types.PhoneIdentifier:
+		phoneNumber, err := formatPhone(c.settings, identifier.PhoneNumber)
+		if err != nil {
+			return "", err
+		}
+		return "tel:" + phoneNumber, nil
+	case types.IpIdentifier:
+		return "ipport:" + identifier.IPAddress, nil
+	}
+	return "", nil
 }
 
 // SimSwapClient is the main client for SIM swap operations
 type SimSwapClient struct {
-	settings types.GlideSdkSettings
+	settings      types.GlideSdkSettings
+	tokens        *auth.TokenManager
+	metrics       types.MetricSink
+	discovery     *auth.Discovery
+	notifications *auth.NotificationHandler
+}
+
+// NewSimSwapClient creates a new SimSwapClient; opts share a session cache or
+// metric sink with other clients, and its user clients share them too
+func NewSimSwapClient(settings types.GlideSdkSettings, opts ...ClientOption) *SimSwapClient {
+	o := newClientOptions(settings, opts)
+	return &SimSwapClient{
+		settings:      settings,
+		tokens:        o.tokens,
+		metrics:       o.metrics,
+		discovery:     o.discovery,
+		notifications: auth.NewNotificationHandler(),
+	}
 }
 
-// NewSimSwapClient creates a new SimSwapClient
-func NewSimSwapClient(settings types.GlideSdkSettings) *SimSwapClient {
-	return &SimSwapClient{settings: settings}
+// NotificationHandler receives the operator's callbacks in CIBA ping and push
+// mode; serve it at the client notification endpoint registered with Glide
+func (c *SimSwapClient) NotificationHandler() http.Handler {
+	return c.notifications
 }
 
 // For creates a SimSwapUserClient for a specific user
 func (c *SimSwapClient) For(identifier types.UserIdentifier) (*SimSwapUserClient, error) {
-	client := NewSimSwapUserClient(c.settings, identifier)
-	err := client.StartSession()
+	return c.ForWithContext(context.Background(), identifier)
+}
+
+// ForWithContext is like For but aborts the session start when ctx is cancelled
+func (c *SimSwapClient) ForWithContext(ctx context.Context, identifier types.UserIdentifier) (*SimSwapUserClient, error) {
+	client := NewSimSwapUserClient(c.settings, identifier, WithTokenManager(c.tokens), WithMetricSink(c.metrics), WithDiscovery(c.discovery))
+	client.notifications = c.notifications
+	key, err := client.tokenKey()
 	if err != nil {
 		return nil, err
 	}
+	// a session consented to earlier, possibly by another process, needs no new auth request
+	if c.tokens.Lookup(ctx, key) != nil {
+		return client, nil
+	}
+	if err := client.StartSessionWithContext(ctx); err != nil {
+		return nil, err
+	}
 	return client, nil
 }
 
@@ -1,51 +1,69 @@
 package services
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
-	"time"
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
 	"github.com/ClearBlockchain/sdk-go/pkg/types"
 	"github.com/ClearBlockchain/sdk-go/pkg/utils"
 )
 
 type TelcoFinderClient struct {
-	settings types.GlideSdkSettings
-	session  *types.Session
+	settings  types.GlideSdkSettings
+	tokens    *auth.TokenManager
+	metrics   types.MetricSink
+	discovery *auth.Discovery
 }
 
-func NewTelcoFinderClient(settings types.GlideSdkSettings) *TelcoFinderClient {
+// NewTelcoFinderClient creates a TelcoFinderClient; opts share a session cache or
+// metric sink with other clients
+func NewTelcoFinderClient(settings types.GlideSdkSettings, opts ...ClientOption) *TelcoFinderClient {
+	o := newClientOptions(settings, opts)
 	return &TelcoFinderClient{
-		settings: settings,
+		settings:  settings,
+		tokens:    o.tokens,
+		metrics:   o.metrics,
+		discovery: o.discovery,
 	}
 }
 
 // NetworkIdForNumber resolves the network ID for a given phone number
 func (c *TelcoFinderClient) NetworkIdForNumber(phoneNumber string, conf types.ApiConfig) (*types.TelcoFinderNetworkIdResponse, error) {
+	return c.NetworkIdForNumberWithContext(context.Background(), phoneNumber, conf)
+}
+
+// NetworkIdForNumberWithContext is like NetworkIdForNumber but aborts when ctx is cancelled
+func (c *TelcoFinderClient) NetworkIdForNumberWithContext(ctx context.Context, phoneNumber string, conf types.ApiConfig) (_ *types.TelcoFinderNetworkIdResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "telco-finder", "telco-finder.network-id", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, fmt.Errorf("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
+	}
+	call.funnel("Glide start")
+	phoneNumber, err = formatPhone(c.settings, phoneNumber)
+	if err != nil {
+		return nil, err
 	}
 
-    session, err := c.getSession(conf.Session)
+    session, err := c.getSession(ctx, conf.Session)
     if err != nil {
-        return nil, fmt.Errorf("[GlideClient] Failed to get session: %w", err)
+        return nil, utils.WrapError("telco-finder.network-id", "Failed to get session", err)
     }
-    fmt.Printf("Debug: Using session with AccessToken: %s...\n", session.AccessToken)
+	call.setOperator(session)
 
 	body, err := json.Marshal(map[string]string{
-		"phoneNumber": utils.FormatPhoneNumber(phoneNumber),
+		"phoneNumber": phoneNumber,
 	})
 	 if err != nil {
-            return nil, fmt.Errorf("[GlideClient] Failed to marshal request body: %w", err)
+            return nil, utils.NewError("telco-finder.network-id", nil, "Failed to marshal request body", err)
      }
 
-    fmt.Printf("Debug: Fetching network ID for number: %s...\n", phoneNumber)
-    fmt.Printf("Debug: Request body: %s\n", body)
-    fmt.Printf("Debug: APIBaseURL: %s\n", c.settings.Internal.APIBaseURL+"/telco-finder/v1/resolve-network-id")
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/telco-finder/v1/resolve-network-id", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/telco-finder/v1/resolve-network-id", utils.FetchXInput{
 		Method: "POST",
+		Idempotent: true,
 		Headers: map[string]string{
 			"Content-Type":  "application/json",
 			"Authorization": "Bearer " + session.AccessToken,
@@ -53,33 +71,55 @@ func (c *TelcoFinderClient) NetworkIdForNumber(phoneNumber string, conf types.Ap
 		Body: string(body),
 	})
 	if err != nil {
-            return nil, fmt.Errorf("[GlideClient] FetchX failed for getting Network ID not found for number: %w", err)
+            return nil, utils.WrapError("telco-finder.network-id", "FetchX failed for getting Network ID", err)
     }
 
 	var result types.TelcoFinderNetworkIdResponse
 	if err := resp.JSON(&result); err != nil {
-            return nil, fmt.Errorf("[GlideClient] Failed to parse response: %w", err)
+            return nil, utils.NewError("telco-finder.network-id", nil, "Failed to parse response", err)
     }
+	call.funnel("Glide success")
 
 	return &result, nil
 }
 
 // LookupIp looks up telco information for an IP address
 func (c *TelcoFinderClient) LookupIp(ip string, conf types.ApiConfig) (*types.TelcoFinderSearchResponse, error) {
-	return c.lookup(fmt.Sprintf("ipport:%s", ip), conf)
+	return c.LookupIpWithContext(context.Background(), ip, conf)
+}
+
+// LookupIpWithContext is like LookupIp but aborts when ctx is cancelled
+func (c *TelcoFinderClient) LookupIpWithContext(ctx context.Context, ip string, conf types.ApiConfig) (_ *types.TelcoFinderSearchResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "telco-finder", "telco-finder.lookup", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	return c.lookup(ctx, call, fmt.Sprintf("ipport:%s", ip), conf)
 }
 
 // LookupNumber looks up telco information for a phone number
 func (c *TelcoFinderClient) LookupNumber(phoneNumber string, conf types.ApiConfig) (*types.TelcoFinderSearchResponse, error) {
-	return c.lookup(fmt.Sprintf("tel:%s", utils.FormatPhoneNumber(phoneNumber)), conf)
+	return c.LookupNumberWithContext(context.Background(), phoneNumber, conf)
 }
 
-func (c *TelcoFinderClient) lookup(subject string, conf types.ApiConfig) (*types.TelcoFinderSearchResponse, error) {
+// LookupNumberWithContext is like LookupNumber but aborts when ctx is cancelled
+func (c *TelcoFinderClient) LookupNumberWithContext(ctx context.Context, phoneNumber string, conf types.ApiConfig) (_ *types.TelcoFinderSearchResponse, err error) {
+	ctx, call := beginCall(ctx, c.metrics, c.settings, "telco-finder", "telco-finder.lookup", conf.SessionIdentifier)
+	defer func() { call.end(err) }()
+	phoneNumber, err = formatPhone(c.settings, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	return c.lookup(ctx, call, fmt.Sprintf("tel:%s", phoneNumber), conf)
+}
+
+func (c *TelcoFinderClient) lookup(ctx context.Context, call *apiCall, subject string, conf types.ApiConfig) (*types.TelcoFinderSearchResponse, error) {
+	ctx, cancel := callContext(ctx, c.settings, conf)
+	defer cancel()
 	if c.settings.Internal.APIBaseURL == "" {
-		return nil, fmt.Errorf("[GlideClient] internal.apiBaseUrl is unset")
+		return nil, &utils.ValidationError{Field: "Internal.APIBaseURL", Message: "internal.apiBaseUrl is unset"}
 	}
+	call.funnel("Glide start")
 
-	session, err := c.getSession(conf.Session)
+	session, err := c.getSession(ctx, conf.Session)
 	if err != nil {
 		return nil, err
 	}
@@ -88,11 +128,12 @@ func (c *TelcoFinderClient) lookup(subject string, conf types.ApiConfig) (*types
 		"resource": subject,
 	})
 	if err != nil {
-		return nil, err
+		return nil, utils.NewError("telco-finder.lookup", nil, "Failed to marshal request body", err)
 	}
 
-	resp, err := utils.FetchX(c.settings.Internal.APIBaseURL+"/telco-finder/v1/search", utils.FetchXInput{
+	resp, err := fetch(ctx, c.settings, c.settings.Internal.APIBaseURL+"/telco-finder/v1/search", utils.FetchXInput{
 		Method: "POST",
+		Idempotent: true,
 		Headers: map[string]string{
 			"Content-Type":  "application/json",
 			"Authorization": "Bearer " + session.AccessToken,
@@ -100,101 +141,32 @@ func (c *TelcoFinderClient) lookup(subject string, conf types.ApiConfig) (*types
 		Body: string(body),
 	})
 	if err != nil {
-		if fetchErr, ok := err.(*utils.FetchError); ok && fetchErr.Response.StatusCode == 404 {
-			return nil, fmt.Errorf("[GlideClient] Lookup failed for subject %s", subject)
-		}
-		return nil, err
+		return nil, utils.WrapError("telco-finder.lookup", fmt.Sprintf("Lookup failed for subject %s", subject), err)
 	}
 
 	var result types.TelcoFinderSearchResponse
 	if err := resp.JSON(&result); err != nil {
-		return nil, err
+		return nil, utils.NewError("telco-finder.lookup", nil, "Failed to parse response", err)
 	}
-
-	result.Properties.OperatorID = result.Properties.OperatorID // Assuming the field name is already correct in Go struct
+	call.operator = result.Properties.OperatorID
+	call.funnel("Glide success")
 
 	return &result, nil
 }
 
-func (c *TelcoFinderClient) getSession(confSession *types.Session) (*types.Session, error) {
-    if confSession != nil {
-        fmt.Println("Debug: Using provided session")
-        return confSession, nil
-    }
-
-    if c.session != nil && c.session.ExpiresAt > time.Now().Add(time.Minute).Unix() && contains(c.session.Scopes, "telco-finder") {
-        fmt.Println("Debug: Using cached session")
-        return c.session, nil
-    }
-
-    fmt.Println("Debug: Generating new session")
-    session, err := c.generateNewSession()
-    if err != nil {
-        return nil, fmt.Errorf("failed to generate new session: %w", err)
-    }
-
-    c.session = session
-    return session, nil
-}
-
-func (c *TelcoFinderClient) generateNewSession() (*types.Session, error) {
-	if c.settings.ClientID == "" || c.settings.ClientSecret == "" {
-		return nil, fmt.Errorf("[GlideClient] Client credentials are required to generate a new session")
+func (c *TelcoFinderClient) getSession(ctx context.Context, confSession *types.Session) (*types.Session, error) {
+	if confSession != nil {
+		return confSession, nil
 	}
 
-	basicAuth := base64.StdEncoding.EncodeToString([]byte(c.settings.ClientID + ":" + c.settings.ClientSecret))
-
-	resp, err := utils.FetchX(c.settings.Internal.AuthBaseURL+"/oauth2/token", utils.FetchXInput{
-		Method: "POST",
-		Headers: map[string]string{
-			"Content-Type":  "application/x-www-form-urlencoded",
-			"Authorization": "Basic " + basicAuth,
-		},
-		Body: url.Values{
-			"grant_type": {"client_credentials"},
-			"scope":      {"telco-finder"},
-		}.Encode(),
+	key := auth.TokenKey{GrantType: auth.GrantClientCredentials, Scope: "telco-finder"}
+	session, err := c.tokens.Get(ctx, key, func(ctx context.Context) (*types.Session, error) {
+		return auth.ClientCredentials(ctx, c.settings, c.discovery, "telco-finder")
 	})
 	if err != nil {
-		if fetchErr, ok := err.(*utils.FetchError); ok {
-			if fetchErr.Response.StatusCode == 401 {
-				return nil, fmt.Errorf("[GlideClient] Invalid client credentials")
-			} else if fetchErr.Response.StatusCode == 400 {
-				var data map[string]interface{}
-				if err := json.Unmarshal([]byte(fetchErr.Data), &data); err == nil {
-					if data["error"] == "invalid_scope" {
-						return nil, fmt.Errorf("[GlideClient] Client does not have required scopes to access this method")
-					}
-				}
-				return nil, fmt.Errorf("[GlideClient] Invalid request")
-			}
-		}
-		return nil, err
-	}
-
-	var body struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int64  `json:"expires_in"`
-		Scope       string `json:"scope"`
-	}
-	if err := resp.JSON(&body); err != nil {
-		return nil, err
-	}
-
-	return &types.Session{
-		AccessToken: body.AccessToken,
-		ExpiresAt:   time.Now().Unix() + body.ExpiresIn,
-		Scopes:      strings.Split(body.Scope, " "),
-	}, nil
-}
-
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+		return nil, utils.WrapError("telco-finder.session", "Failed to generate new session", err)
 	}
-	return false
+	return session, nil
 }
 
 func (c *TelcoFinderClient) GetHello() (string) {
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// cibaNotifyServer records the client_notification_token of the backchannel
+// request, answered with backchannel, and counts token requests, which it always grants
+type cibaNotifyServer struct {
+	mu          sync.Mutex
+	backchannel string
+	notifyToken string
+	tokenCalls  int
+}
+
+func newCIBANotifyServer(t *testing.T, mode types.CIBADeliveryMode) (*cibaNotifyServer, types.GlideSdkSettings) {
+	s := &cibaNotifyServer{backchannel: `{"auth_req_id":"req","expires_in":120,"interval":1}`}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/.well-known/") {
+			// no discovery, the SDK falls back to the default paths
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth2/backchannel-authentication" {
+			s.notifyToken = r.PostForm.Get("client_notification_token")
+			w.Write([]byte(s.backchannel))
+			return
+		}
+		s.tokenCalls++
+		w.Write([]byte(`{"access_token":"pinged","expires_in":3600,"scope":"sim-swap"}`))
+	}))
+	t.Cleanup(server.Close)
+	return s, types.GlideSdkSettings{
+		ClientID:         "client",
+		ClientSecret:     "secret",
+		CIBADeliveryMode: mode,
+		Internal:         types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+}
+
+func (s *cibaNotifyServer) state() (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notifyToken, s.tokenCalls
+}
+
+func notify(handler http.Handler, token, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/ciba/notify", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestCIBANotifications(t *testing.T) {
+	phone := types.PhoneIdentifier{PhoneNumber: "+555123456789"}
+
+	t.Run("push delivers the session", func(t *testing.T) {
+		server, settings := newCIBANotifyServer(t, types.CIBAPush)
+		client := services.NewSimSwapClient(settings)
+		userClient, err := client.For(phone)
+		assert.NoError(t, err)
+		token, _ := server.state()
+		assert.NotEmpty(t, token)
+
+		done := make(chan error, 1)
+		go func() { done <- userClient.PollForSession(context.Background(), nil) }()
+
+		handler := client.NotificationHandler()
+		body := `{"auth_req_id":"req","access_token":"pushed","expires_in":3600,"scope":"sim-swap"}`
+		assert.Equal(t, http.StatusUnauthorized, notify(handler, "", body))
+		assert.Equal(t, http.StatusUnauthorized, notify(handler, "wrong", body))
+		assert.Equal(t, http.StatusBadRequest, notify(handler, token, `{"auth_req_id":"other"}`))
+		assert.Equal(t, http.StatusNoContent, notify(handler, token, body))
+		// the token is single use
+		assert.Equal(t, http.StatusUnauthorized, notify(handler, token, body))
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("session was not resolved by the push")
+		}
+		_, tokenCalls := server.state()
+		assert.Equal(t, 0, tokenCalls)
+	})
+
+	t.Run("ping fetches the token once", func(t *testing.T) {
+		server, settings := newCIBANotifyServer(t, types.CIBAPing)
+		client := services.NewSimSwapClient(settings)
+		userClient, err := client.For(phone)
+		assert.NoError(t, err)
+
+		// nothing is polled before the callback
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, userClient.PollForSession(ctx, nil), context.DeadlineExceeded)
+		_, tokenCalls := server.state()
+		assert.Equal(t, 0, tokenCalls)
+
+		token, _ := server.state()
+		assert.Equal(t, http.StatusNoContent, notify(client.NotificationHandler(), token, `{"auth_req_id":"req"}`))
+		assert.NoError(t, userClient.PollForSession(context.Background(), nil))
+		_, tokenCalls = server.state()
+		assert.Equal(t, 1, tokenCalls)
+	})
+
+	t.Run("waits without an expiry", func(t *testing.T) {
+		server, settings := newCIBANotifyServer(t, types.CIBAPing)
+		server.backchannel = `{"auth_req_id":"req"}`
+		userClient, err := services.NewSimSwapClient(settings).For(phone)
+		assert.NoError(t, err)
+
+		var progress []types.CIBAPollProgress
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err = userClient.PollForSession(ctx, func(p types.CIBAPollProgress) { progress = append(progress, p) })
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		if assert.Len(t, progress, 1) {
+			// neither the wait nor the expiry is known until the callback
+			assert.Zero(t, progress[0].Interval)
+			assert.True(t, progress[0].ExpiresAt.IsZero())
+		}
+	})
+
+	t.Run("pushed denial", func(t *testing.T) {
+		server, settings := newCIBANotifyServer(t, types.CIBAPush)
+		client := services.NewSimSwapClient(settings)
+		userClient, err := client.For(phone)
+		assert.NoError(t, err)
+
+		token, _ := server.state()
+		assert.Equal(t, http.StatusNoContent, notify(client.NotificationHandler(), token, `{"auth_req_id":"req","error":"access_denied","error_description":"user declined"}`))
+		err = userClient.PollForSession(context.Background(), nil)
+		assert.ErrorIs(t, err, utils.ErrAccessDenied)
+	})
+
+	t.Run("standalone user clients can only poll", func(t *testing.T) {
+		_, settings := newCIBANotifyServer(t, types.CIBAPing)
+		err := services.NewSimSwapUserClient(settings, phone).StartSession()
+		assert.ErrorIs(t, err, utils.ErrValidation)
+	})
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCIBAServer answers backchannel authentication with backchannel and every
+// token poll with the next of polls, repeating the last one. OAuth errors are
+// sent as 400 and TOO_MANY_REQUESTS as 429.
+func newCIBAServer(t *testing.T, backchannel string, polls ...string) (types.GlideSdkSettings, *int) {
+	var mu sync.Mutex
+	var starts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/.well-known/") {
+			// no discovery, the SDK falls back to the default paths
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth2/backchannel-authentication" {
+			starts++
+			w.Write([]byte(backchannel))
+			return
+		}
+		poll := polls[0]
+		if len(polls) > 1 {
+			polls = polls[1:]
+		}
+		switch {
+		case strings.HasPrefix(poll, `{"error"`):
+			w.WriteHeader(http.StatusBadRequest)
+		case strings.Contains(poll, "TOO_MANY_REQUESTS"):
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		w.Write([]byte(poll))
+	}))
+	t.Cleanup(server.Close)
+	return types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Internal:     types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}, &starts
+}
+
+func TestCIBAPolling(t *testing.T) {
+	phone := types.PhoneIdentifier{PhoneNumber: "+555123456789"}
+
+	t.Run("honours interval and slow_down", func(t *testing.T) {
+		settings, starts := newCIBAServer(t, `{"auth_req_id":"req","expires_in":120,"interval":1}`,
+			`{"error":"authorization_pending"}`, `{"error":"slow_down"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var progress []types.CIBAPollProgress
+		start := time.Now()
+		err := userClient.PollForSession(ctx, func(p types.CIBAPollProgress) {
+			progress = append(progress, p)
+			if p.Status == "slow_down" {
+				cancel()
+			}
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		if assert.Len(t, progress, 2) {
+			assert.Equal(t, "authorization_pending", progress[0].Status)
+			assert.Equal(t, time.Second, progress[0].Interval)
+			assert.Equal(t, "slow_down", progress[1].Status)
+			assert.Equal(t, 6*time.Second, progress[1].Interval)
+			assert.WithinDuration(t, time.Now().Add(2*time.Minute), progress[1].ExpiresAt, 5*time.Second)
+		}
+		// both polls used the same authentication request
+		assert.Equal(t, 1, *starts)
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		settings, _ := newCIBAServer(t, `{"auth_req_id":"req","expires_in":120,"interval":1}`, `{"error":"access_denied"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		err := userClient.PollForSession(context.Background(), nil)
+		assert.True(t, errors.Is(err, utils.ErrAccessDenied), "got %v", err)
+		assert.Equal(t, "access_denied", utils.ErrorClass(err))
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		settings, _ := newCIBAServer(t, `{"auth_req_id":"req","expires_in":120,"interval":1}`, `{"error":"expired_token"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		err := userClient.PollForSession(context.Background(), nil)
+		assert.True(t, errors.Is(err, utils.ErrAuthExpired), "got %v", err)
+	})
+
+	t.Run("expires before the next poll", func(t *testing.T) {
+		settings, _ := newCIBAServer(t, `{"auth_req_id":"req","expires_in":1,"interval":1}`, `{"error":"authorization_pending"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		err := userClient.PollForSession(context.Background(), nil)
+		assert.True(t, errors.Is(err, utils.ErrAuthExpired), "got %v", err)
+	})
+
+	t.Run("rate limited polls keep the request", func(t *testing.T) {
+		settings, starts := newCIBAServer(t, `{"auth_req_id":"req","expires_in":120,"interval":1,"consentUrl":"https://consent.example.com"}`,
+			`{"status":429,"code":"TOO_MANY_REQUESTS","message":"slow down"}`, `{"access_token":"token","expires_in":3600,"scope":"sim-swap"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		assert.NoError(t, userClient.StartSession())
+		assert.NotEmpty(t, userClient.GetConsentURL())
+
+		attempts := 0
+		err := userClient.PollForSession(context.Background(), func(types.CIBAPollProgress) { attempts++ })
+		assert.True(t, errors.Is(err, utils.ErrRateLimited), "got %v", err)
+		assert.False(t, errors.Is(err, utils.ErrConsentRequired))
+		assert.Equal(t, 0, attempts)
+
+		// polling again resumes the same request
+		assert.NoError(t, userClient.PollForSession(context.Background(), nil))
+		assert.Equal(t, 1, *starts)
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		settings, _ := newCIBAServer(t, `{"auth_req_id":"req","expires_in":120,"interval":1}`,
+			`{"error":"authorization_pending"}`, `{"access_token":"token","expires_in":3600,"scope":"sim-swap"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		attempts := 0
+		err := userClient.PollForSession(context.Background(), func(types.CIBAPollProgress) { attempts++ })
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("does not ask for consent again before the session expires", func(t *testing.T) {
+		settings, starts := newCIBAServer(t, `{"auth_req_id":"req","expires_in":120,"interval":1}`,
+			`{"access_token":"token","expires_in":5,"scope":"sim-swap"}`)
+		userClient := services.NewSimSwapUserClient(settings, phone)
+		assert.NoError(t, userClient.StartSession())
+		assert.NoError(t, userClient.PollAndWaitForSession())
+
+		// past the point a renewable session would be refreshed
+		time.Sleep(3 * time.Second)
+		for i := 0; i < 2; i++ {
+			_, err := userClient.Check(types.SimSwapCheckParams{}, types.ApiConfig{})
+			assert.NoError(t, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 1, *starts)
+	})
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// clientAuthRequest is what a token or backchannel request authenticated with
+type clientAuthRequest struct {
+	path          string
+	authorization string
+	form          url.Values
+	peerCerts     int
+}
+
+func newClientAuthServer(t *testing.T, tlsServer bool) (*httptest.Server, func() []clientAuthRequest) {
+	var mu sync.Mutex
+	var requests []clientAuthRequest
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		r.ParseForm()
+		req := clientAuthRequest{path: r.URL.Path, authorization: r.Header.Get("Authorization"), form: r.PostForm}
+		if r.TLS != nil {
+			req.peerCerts = len(r.TLS.PeerCertificates)
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","expires_in":3600,"scope":"sim-swap","auth_req_id":"req"}`))
+	})
+	var server *httptest.Server
+	if tlsServer {
+		server = httptest.NewUnstartedServer(handler)
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+	return server, func() []clientAuthRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]clientAuthRequest(nil), requests...)
+	}
+}
+
+func newClientCertificate(t *testing.T) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// opaqueSigner hides the concrete type of its key
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+func TestPrivateKeyJWT(t *testing.T) {
+	ctx := context.Background()
+	ecKey := newECKey(t, "ec")
+	// a signer that only exposes crypto.Signer, like an HSM or KMS key
+	opaque := signingKey{kid: "kms", alg: "ES256", signer: opaqueSigner{ecKey.signer}}
+	for _, key := range []signingKey{newRSAKey(t, "rsa"), ecKey, opaque} {
+		server, requests := newClientAuthServer(t, false)
+		settings := types.GlideSdkSettings{
+			ClientID:   "client",
+			ClientAuth: types.ClientAuth{Method: types.PrivateKeyJWT, SigningKey: key.signer, KeyID: key.kid},
+			Internal:   types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+		}
+		_, err := auth.ClientCredentials(ctx, settings, nil, "sim-swap")
+		assert.NoError(t, err, key.alg)
+		assert.NoError(t, services.NewSimSwapUserClient(settings, types.PhoneIdentifier{PhoneNumber: "+555123456789"}).StartSession(), key.alg)
+
+		// the assertion verifies like an id_token against the client's public key
+		jwks := newOIDCServer(t, key)
+		verifier := auth.NewIDTokenVerifier(types.GlideSdkSettings{ClientID: server.URL + "/oauth2/token", Internal: types.InternalSettings{AuthBaseURL: jwks.URL}}, nil)
+		assert.Len(t, requests(), 2)
+		var assertions []string
+		for _, req := range requests() {
+			assert.Empty(t, req.authorization, req.path)
+			assert.Equal(t, "client", req.form.Get("client_id"))
+			assert.Equal(t, auth.ClientAssertionType, req.form.Get("client_assertion_type"))
+			_, err := verifier.Verify(ctx, req.form.Get("client_assertion"), "")
+			// the signature checks out, only the issuer differs as the client issued it
+			assert.ErrorContains(t, err, `issuer "client"`, key.alg)
+			assertions = append(assertions, req.form.Get("client_assertion"))
+		}
+		assert.NotEqual(t, assertions[0], assertions[1])
+	}
+
+	_, err := auth.ClientCredentials(ctx, types.GlideSdkSettings{ClientID: "client", ClientAuth: types.ClientAuth{Method: types.PrivateKeyJWT}}, nil, "sim-swap")
+	assert.ErrorIs(t, err, utils.ErrValidation)
+
+	// an assertion is single use, so a failed request is not sent again with it
+	var attempts int32
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			atomic.AddInt32(&attempts, 1)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	_, err = auth.ClientCredentials(ctx, types.GlideSdkSettings{
+		ClientID:   "client",
+		ClientAuth: types.ClientAuth{Method: types.PrivateKeyJWT, SigningKey: newECKey(t, "ec").signer},
+		Internal:   types.InternalSettings{AuthBaseURL: unavailable.URL, APIBaseURL: unavailable.URL},
+	}, nil, "sim-swap")
+	assert.ErrorIs(t, err, utils.ErrUpstreamUnavailable)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestTLSClientAuth(t *testing.T) {
+	server, requests := newClientAuthServer(t, true)
+	settings := types.GlideSdkSettings{
+		ClientID:   "client",
+		ClientAuth: types.ClientAuth{Method: types.TLSClientAuth, Certificate: newClientCertificate(t)},
+		// trusts the test server
+		Transport: server.Client().Transport,
+		Internal:  types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+	_, err := auth.ClientCredentials(context.Background(), settings, nil, "sim-swap")
+	assert.NoError(t, err)
+	if assert.Len(t, requests(), 1) {
+		assert.Equal(t, 1, requests()[0].peerCerts)
+		assert.Empty(t, requests()[0].authorization)
+		assert.Equal(t, "client", requests()[0].form.Get("client_id"))
+	}
+
+	settings.ClientAuth.Certificate = nil
+	_, err = auth.ClientCredentials(context.Background(), settings, nil, "sim-swap")
+	assert.ErrorIs(t, err, utils.ErrValidation)
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/config"
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlConfig = `
+clientId: file-client
+clientSecret: file-secret
+timeout: 10s
+profile: sandbox
+profiles:
+  sandbox:
+    authBaseUrl: https://oidc.sandbox.example.com
+    apiBaseUrl: https://api.sandbox.example.com
+  production:
+    timeout: 30s
+    cibaDeliveryMode: ping
+`
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigLoader(t *testing.T) {
+	for _, name := range []string{"GLIDE_PROFILE", "GLIDE_CLIENT_ID", "GLIDE_CLIENT_SECRET", "GLIDE_AUTH_BASE_URL", "GLIDE_API_BASE_URL", "GLIDE_TIMEOUT"} {
+		t.Setenv(name, "")
+	}
+
+	t.Run("applies the profile named in the file", func(t *testing.T) {
+		settings, err := config.Load(writeConfig(t, "glide.yaml", yamlConfig), "")
+		assert.NoError(t, err)
+		assert.Equal(t, "file-client", settings.ClientID)
+		assert.Equal(t, "https://oidc.sandbox.example.com", settings.Internal.AuthBaseURL)
+		assert.Equal(t, 10*time.Second, settings.Timeout)
+	})
+
+	t.Run("environment overrides the profile", func(t *testing.T) {
+		t.Setenv("GLIDE_PROFILE", config.Production)
+		t.Setenv("GLIDE_CLIENT_SECRET", "env-secret")
+		settings, err := config.Load(writeConfig(t, "glide.yaml", yamlConfig), "")
+		assert.NoError(t, err)
+		assert.Equal(t, "env-secret", settings.ClientSecret)
+		assert.Equal(t, 30*time.Second, settings.Timeout)
+		assert.Equal(t, types.CIBAPing, settings.CIBADeliveryMode)
+		assert.Equal(t, glide.DefaultAuthBaseURL, settings.Internal.AuthBaseURL)
+
+		_, err = glide.NewGlideClient(settings)
+		assert.NoError(t, err)
+	})
+
+	t.Run("reads JSON", func(t *testing.T) {
+		path := writeConfig(t, "glide.json", `{"clientId": "json-client", "profiles": {"staging": {"apiBaseUrl": "https://api.staging.example.com"}}}`)
+		settings, err := config.Load(path, config.Staging)
+		assert.NoError(t, err)
+		assert.Equal(t, "json-client", settings.ClientID)
+		assert.Equal(t, "https://api.staging.example.com", settings.Internal.APIBaseURL)
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+		_, err := config.Parse([]byte("clientID: typo\n"), "")
+		assert.ErrorIs(t, err, utils.ErrValidation)
+
+		_, err = config.Parse([]byte(yamlConfig), config.Staging)
+		assert.ErrorContains(t, err, `profile "staging" is not defined`)
+
+		_, err = config.Parse([]byte("timeout: soon\nauthBaseUrl: oidc.example.com\n"), "")
+		assert.ErrorIs(t, err, utils.ErrValidation)
+		assert.ErrorContains(t, err, `timeout "soon" is not a duration`)
+		assert.ErrorContains(t, err, "clientId is required")
+		assert.ErrorContains(t, err, "is not an absolute http(s) URL")
+	})
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Internal: types.InternalSettings{
+			AuthBaseURL: server.URL,
+			APIBaseURL:  server.URL,
+		},
+	})
+	assert.NoError(t, err)
+
+	t.Run("per-call timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{Timeout: 50 * time.Millisecond})
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "error should wrap context.DeadlineExceeded: %v", err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+		_, err := glideClient.MagicAuth.StartAuthWithContext(ctx, types.MagicAuthStartProps{PhoneNumber: "+555123456789"}, types.ApiConfig{})
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, context.Canceled), "error should wrap context.Canceled: %v", err)
+	})
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDiscoveryServer publishes metadata, if not nil, and grants every other
+// request, recording the paths it was sent to
+func newDiscoveryServer(t *testing.T, metadata func(base string) map[string]string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var paths []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/.well-known/openid-configuration" {
+			if metadata == nil {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(metadata(server.URL))
+			return
+		}
+		w.Write([]byte(`{"access_token":"token","expires_in":3600,"scope":"telco-finder","auth_req_id":"req"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("resolves published endpoints", func(t *testing.T) {
+		server, paths := newDiscoveryServer(t, func(base string) map[string]string {
+			return map[string]string{
+				"issuer":                              base,
+				"authorization_endpoint":              base + "/v2/authorize",
+				"token_endpoint":                      base + "/v2/token",
+				"backchannel_authentication_endpoint": base + "/v2/bc-authorize",
+			}
+		})
+		settings := types.GlideSdkSettings{ClientID: "client", ClientSecret: "secret", Internal: types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL}}
+		discovery := auth.NewDiscovery(settings)
+
+		_, err := auth.ClientCredentials(ctx, settings, discovery, "telco-finder")
+		assert.NoError(t, err)
+		assert.NoError(t, services.NewSimSwapUserClient(settings, types.PhoneIdentifier{PhoneNumber: "+555123456789"}, services.WithDiscovery(discovery)).StartSession())
+		authURL, err := services.NewNumberVerifyClient(settings, services.WithDiscovery(discovery)).GetAuthURL()
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(authURL.URL, server.URL+"/v2/authorize?"))
+		// the configuration is fetched once and shared
+		assert.Equal(t, []string{"/.well-known/openid-configuration", "/v2/token", "/v2/bc-authorize"}, paths())
+	})
+
+	t.Run("falls back to the default paths", func(t *testing.T) {
+		server, paths := newDiscoveryServer(t, nil)
+		settings := types.GlideSdkSettings{ClientID: "client", ClientSecret: "secret", Internal: types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL}}
+		discovery := auth.NewDiscovery(settings)
+
+		_, err := auth.ClientCredentials(ctx, settings, discovery, "telco-finder")
+		assert.NoError(t, err)
+		_, err = auth.ClientCredentials(ctx, settings, discovery, "telco-finder")
+		assert.NoError(t, err)
+		endpoints := discovery.Endpoints(ctx)
+		assert.Equal(t, server.URL+"/oauth2/auth", endpoints.Authorization)
+		assert.Equal(t, server.URL+"/oauth2/backchannel-authentication", endpoints.BackchannelAuthentication)
+		// the failure is remembered rather than retried on every request
+		assert.Equal(t, []string{"/.well-known/openid-configuration", "/oauth2/token", "/oauth2/token"}, paths())
+	})
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newErrorTestClient answers token requests with tokenStatus and API requests with apiStatus
+func newErrorTestClient(t *testing.T, tokenStatus int, tokenBody string, apiStatus int) (*glide.GlideClient, types.GlideSdkSettings) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth2/token" {
+			w.WriteHeader(tokenStatus)
+			w.Write([]byte(tokenBody))
+			return
+		}
+		w.WriteHeader(apiStatus)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	settings := types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Internal: types.InternalSettings{
+			AuthBaseURL: server.URL,
+			APIBaseURL:  server.URL,
+		},
+	}
+	glideClient, err := glide.NewGlideClient(settings)
+	assert.NoError(t, err)
+	return glideClient, settings
+}
+
+func TestErrorClassification(t *testing.T) {
+	validToken := `{"access_token":"token","expires_in":3600,"scope":"telco-finder"}`
+	cases := []struct {
+		name        string
+		tokenStatus int
+		tokenBody   string
+		apiStatus   int
+		kind        error
+	}{
+		{"invalid credentials", http.StatusUnauthorized, `{"error":"invalid_client"}`, http.StatusOK, utils.ErrInvalidCredentials},
+		{"insufficient scope", http.StatusBadRequest, `{"error":"invalid_scope"}`, http.StatusOK, utils.ErrInsufficientScope},
+		{"number not supported", http.StatusOK, validToken, http.StatusNotFound, utils.ErrNumberNotSupported},
+		{"rate limited", http.StatusOK, validToken, http.StatusTooManyRequests, utils.ErrRateLimited},
+		{"upstream unavailable", http.StatusOK, validToken, http.StatusServiceUnavailable, utils.ErrUpstreamUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			glideClient, _ := newErrorTestClient(t, tc.tokenStatus, tc.tokenBody, tc.apiStatus)
+			_, err := glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, tc.kind), "expected %v, got %v", tc.kind, err)
+
+			var fetchErr *utils.FetchError
+			assert.True(t, errors.As(err, &fetchErr), "error should wrap the FetchError")
+			var glideErr *utils.GlideError
+			assert.True(t, errors.As(err, &glideErr))
+		})
+	}
+
+	t.Run("sim swap 404 keeps the fetch error", func(t *testing.T) {
+		_, settings := newErrorTestClient(t, http.StatusOK, validToken, http.StatusNotFound)
+		userClient := services.NewSimSwapUserClient(settings, types.IpIdentifier{IPAddress: "80.58.0.0"})
+		_, err := userClient.Check(types.SimSwapCheckParams{PhoneNumber: "+555123456789"}, types.ApiConfig{Session: &types.Session{AccessToken: "token"}})
+		assert.True(t, errors.Is(err, utils.ErrNumberNotSupported))
+		var fetchErr *utils.FetchError
+		assert.True(t, errors.As(err, &fetchErr))
+		assert.Equal(t, http.StatusNotFound, fetchErr.Response.StatusCode)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, settings := newErrorTestClient(t, http.StatusOK, validToken, http.StatusOK)
+		userClient := services.NewSimSwapUserClient(settings, types.IpIdentifier{IPAddress: "80.58.0.0"})
+		_, err := userClient.Check(types.SimSwapCheckParams{}, types.ApiConfig{})
+		assert.True(t, errors.Is(err, utils.ErrValidation))
+	})
+}
+
+func TestProblemDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-correlator", "b4333c46-49c0-4f62-80d7-f0ef930f1c46")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":404,"code":"SIM_SWAP.UNKNOWN_PHONE_NUMBER","message":"SIM Swap can't be checked because the phone number is unknown."}`))
+	}))
+	defer server.Close()
+	settings := types.GlideSdkSettings{
+		ClientID: "client",
+		Internal: types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+	userClient := services.NewSimSwapUserClient(settings, types.PhoneIdentifier{PhoneNumber: "+555123456789"})
+
+	_, err := userClient.RetrieveDate(types.SimSwapRetrieveDateParams{}, types.ApiConfig{Session: &types.Session{AccessToken: "token"}})
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, utils.ErrNumberNotSupported))
+	fetchErr, ok := utils.AsFetchError(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, fetchErr.Status())
+	assert.Equal(t, "SIM_SWAP.UNKNOWN_PHONE_NUMBER", fetchErr.Code())
+	assert.Equal(t, "SIM Swap can't be checked because the phone number is unknown.", fetchErr.Message())
+	assert.Equal(t, "b4333c46-49c0-4f62-80d7-f0ef930f1c46", fetchErr.CorrelationID)
+	assert.Contains(t, err.Error(), "SIM_SWAP.UNKNOWN_PHONE_NUMBER")
+}
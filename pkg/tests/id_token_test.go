@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// signingKey is a key of the test issuer
+type signingKey struct {
+	kid    string
+	alg    string
+	signer crypto.Signer
+}
+
+func (k signingKey) jwk() map[string]string {
+	switch pub := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig", "alg": k.alg,
+			"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "use": "sig", "crv": "P-256",
+			"x": b64(pub.X.FillBytes(make([]byte, 32))), "y": b64(pub.Y.FillBytes(make([]byte, 32)))}
+	}
+	return nil
+}
+
+func (k signingKey) sign(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch signer := k.signer.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, signer, digest[:])
+		assert.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// oidcServer is an authorization server publishing its configuration and keys
+// and answering token requests with idToken
+type oidcServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	keys        []signingKey
+	idToken     string
+	jwksFetches int
+}
+
+func newOIDCServer(t *testing.T, keys ...signingKey) *oidcServer {
+	s := &oidcServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":         s.URL,
+				"token_endpoint": s.URL + "/oauth2/token",
+				"jwks_uri":       s.URL + "/jwks",
+			})
+		case "/jwks":
+			s.jwksFetches++
+			var jwks []map[string]string
+			for _, key := range s.keys {
+				jwks = append(jwks, key.jwk())
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": jwks})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "expires_in": 3600, "scope": "openid", "id_token": s.idToken})
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *oidcServer) settings() types.GlideSdkSettings {
+	return types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Internal:     types.InternalSettings{AuthBaseURL: s.URL, APIBaseURL: s.URL},
+	}
+}
+
+func (s *oidcServer) claims(nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   s.URL,
+		"sub":   "subscriber",
+		"aud":   "client",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": nonce,
+	}
+}
+
+func newRSAKey(t *testing.T, kid string) signingKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return signingKey{kid: kid, alg: "RS256", signer: key}
+}
+
+func newECKey(t *testing.T, kid string) signingKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	return signingKey{kid: kid, alg: "ES256", signer: key}
+}
+
+func TestIDTokenVerification(t *testing.T) {
+	rsaKey, ecKey := newRSAKey(t, "rsa"), newECKey(t, "ec")
+	server := newOIDCServer(t, rsaKey, ecKey)
+	verifier := auth.NewIDTokenVerifier(server.settings(), nil)
+	ctx := context.Background()
+
+	for _, key := range []signingKey{rsaKey, ecKey} {
+		claims, err := verifier.Verify(ctx, key.sign(t, server.claims("nonce")), "nonce")
+		assert.NoError(t, err, key.alg)
+		if assert.NotNil(t, claims) {
+			assert.Equal(t, "subscriber", claims.Subject)
+			assert.Equal(t, []string{"client"}, claims.Audience)
+			assert.Equal(t, "subscriber", claims.Raw["sub"])
+		}
+	}
+
+	invalid := map[string]func(map[string]interface{}){
+		"issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.example" },
+		"audience": func(c map[string]interface{}) { c["aud"] = []string{"other"} },
+		"expired":  func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"nonce":    func(c map[string]interface{}) { c["nonce"] = "replayed" },
+	}
+	for name, mutate := range invalid {
+		claims := server.claims("nonce")
+		mutate(claims)
+		_, err := verifier.Verify(ctx, rsaKey.sign(t, claims), "nonce")
+		assert.ErrorIs(t, err, utils.ErrInvalidToken, name)
+	}
+
+	token := rsaKey.sign(t, server.claims("nonce"))
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(map[string]interface{}{"iss": server.URL, "sub": "admin", "aud": "client", "exp": time.Now().Add(time.Hour).Unix()})
+	_, err := verifier.Verify(ctx, parts[0]+"."+b64(forged)+"."+parts[2], "")
+	assert.ErrorIs(t, err, utils.ErrInvalidToken)
+	unsigned, _ := json.Marshal(map[string]string{"alg": "none"})
+	_, err = verifier.Verify(ctx, b64(unsigned)+"."+parts[1]+".", "")
+	assert.ErrorIs(t, err, utils.ErrInvalidToken)
+
+	t.Run("key rotation", func(t *testing.T) {
+		fetches := server.jwksFetches
+		rotated := newRSAKey(t, "rotated")
+		server.mu.Lock()
+		server.keys = []signingKey{rotated}
+		server.mu.Unlock()
+		_, err := verifier.Verify(ctx, rotated.sign(t, server.claims("")), "")
+		assert.NoError(t, err)
+		assert.Equal(t, fetches+1, server.jwksFetches)
+
+		// unknown key IDs do not refetch again right away
+		_, err = verifier.Verify(ctx, newRSAKey(t, "made-up").sign(t, server.claims("")), "")
+		assert.ErrorIs(t, err, utils.ErrInvalidToken)
+		assert.Equal(t, fetches+1, server.jwksFetches)
+	})
+}
+
+func TestNumberVerifyIDToken(t *testing.T) {
+	key := newECKey(t, "ec")
+	server := newOIDCServer(t, key)
+	client := services.NewNumberVerifyClient(server.settings())
+	authURL, err := client.GetAuthURL()
+	assert.NoError(t, err)
+
+	server.idToken = key.sign(t, server.claims(authURL.Nonce))
+	userClient, err := client.ForCallback(map[string][]string{"state": {authURL.State}, "code": {"code"}}, nil)
+	assert.NoError(t, err)
+	if assert.NotNil(t, userClient) && assert.NotNil(t, userClient.IDTokenClaims()) {
+		assert.Equal(t, "subscriber", userClient.IDTokenClaims().Subject)
+	}
+
+	// an id_token issued for another auth URL is rejected
+	_, err = client.For(types.NumberVerifyClientForParams{Code: "code", Nonce: "other"})
+	assert.ErrorIs(t, err, utils.ErrInvalidToken)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRevocationServer issues CIBA sessions, knows "active-token" and records
+// the revoked tokens
+func newRevocationServer(t *testing.T) (types.GlideSdkSettings, func() []string) {
+	var mu sync.Mutex
+	var revoked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/") {
+			http.NotFound(w, r)
+			return
+		}
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/backchannel-authentication":
+			w.Write([]byte(`{"auth_req_id":"req","expires_in":120,"interval":1}`))
+		case "/oauth2/token":
+			w.Write([]byte(`{"access_token":"ciba-token","expires_in":3600,"scope":"sim-swap"}`))
+		case "/oauth2/revoke":
+			mu.Lock()
+			revoked = append(revoked, r.PostForm.Get("token_type_hint")+":"+r.PostForm.Get("token"))
+			mu.Unlock()
+		case "/oauth2/introspect":
+			if r.PostForm.Get("token") != "active-token" {
+				w.Write([]byte(`{"active":false}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"active": true, "scope": "sim-swap", "client_id": "client", "sub": "subscriber",
+				"exp": 2000000000, "iat": 1700000000, "aud": []string{"api"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	settings := types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Internal:     types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+	return settings, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), revoked...)
+	}
+}
+
+func TestRevocationAndIntrospection(t *testing.T) {
+	t.Run("introspects tokens", func(t *testing.T) {
+		settings, _ := newRevocationServer(t)
+		client, err := glide.NewGlideClient(settings)
+		assert.NoError(t, err)
+
+		result, err := client.Introspect("active-token")
+		assert.NoError(t, err)
+		assert.True(t, result.Active)
+		assert.Equal(t, "sim-swap", result.Scope)
+		assert.Equal(t, "subscriber", result.Subject)
+		assert.Equal(t, int64(2000000000), result.ExpiresAt)
+		assert.Equal(t, []interface{}{"api"}, result.Raw["aud"])
+
+		result, err = client.Introspect("revoked-token")
+		assert.NoError(t, err)
+		assert.False(t, result.Active)
+
+		_, err = client.Introspect("")
+		assert.ErrorIs(t, err, utils.ErrValidation)
+	})
+
+	t.Run("revokes tokens", func(t *testing.T) {
+		settings, revoked := newRevocationServer(t)
+		client, err := glide.NewGlideClient(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, client.Revoke("some-token", "access_token"))
+		assert.Equal(t, []string{"access_token:some-token"}, revoked())
+
+		settings.ClientSecret = "wrong"
+		client, err = glide.NewGlideClient(settings)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, client.Revoke("some-token", ""), utils.ErrInvalidCredentials)
+	})
+
+	t.Run("closing a user client revokes its session", func(t *testing.T) {
+		settings, revoked := newRevocationServer(t)
+		userClient := services.NewSimSwapUserClient(settings, types.PhoneIdentifier{PhoneNumber: "+555123456789"})
+		assert.NoError(t, userClient.StartSession())
+		assert.NoError(t, userClient.PollAndWaitForSession())
+
+		assert.NoError(t, userClient.Close(context.Background()))
+		assert.Equal(t, []string{"access_token:ciba-token"}, revoked())
+		// nothing left to revoke
+		assert.NoError(t, userClient.Close(context.Background()))
+		assert.Len(t, revoked(), 1)
+	})
+}
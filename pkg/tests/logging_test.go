@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructuredLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Transport:    &recordingTransport{},
+		Logger:       logger,
+		Internal: types.InternalSettings{
+			AuthBaseURL: "https://auth.example.invalid",
+			APIBaseURL:  "https://api.example.invalid",
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"fetching new session"`)
+	assert.Contains(t, out, `"msg":"http request"`)
+	assert.Contains(t, out, `"url":"https://api.example.invalid/telco-finder/v1/resolve-network-id"`)
+	assert.NotContains(t, out, `"token"`, "access tokens must never be logged")
+	assert.NotContains(t, out, "555123456789", "request bodies must never be logged")
+
+	buf.Reset()
+	_, err = glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"msg":"using cached session"`)
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/metrics/otelmetrics"
+	"github.com/ClearBlockchain/sdk-go/pkg/metrics/prommetrics"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingSink keeps every metric reported to it
+type recordingSink struct {
+	mu      sync.Mutex
+	metrics []types.MetricInfo
+}
+
+func (s *recordingSink) Report(m types.MetricInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, m)
+}
+
+func TestMetricSinks(t *testing.T) {
+	recorder := &recordingSink{}
+	collector := prommetrics.New("test")
+	reader := sdkmetric.NewManualReader()
+	otelSink, err := otelmetrics.New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	assert.NoError(t, err)
+
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Transport:    &recordingTransport{},
+		MetricSink:   utils.MultiMetricSink(recorder, collector, otelSink),
+		Internal: types.InternalSettings{
+			AuthBaseURL: "https://auth.example.invalid",
+			APIBaseURL:  "https://api.example.invalid",
+		},
+	})
+	assert.NoError(t, err)
+	defer glideClient.Close(context.Background())
+
+	_, err = glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+	assert.NoError(t, err)
+	_, err = glideClient.TelcoFinder.NetworkIdForNumber("0501234567", types.ApiConfig{})
+	assert.Error(t, err)
+
+	assert.Len(t, recorder.metrics, 2)
+	for i, outcome := range []string{"success", "validation"} {
+		m := recorder.metrics[i]
+		assert.Equal(t, "telco-finder", m.Api)
+		assert.Equal(t, "telco-finder.network-id", m.MetricName)
+		assert.Equal(t, "client", m.ClientId)
+		assert.Equal(t, outcome, m.Outcome)
+		assert.Positive(t, m.Latency)
+	}
+
+	assert.Equal(t, 2, testutil.CollectAndCount(collector, "test_glide_calls_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(collector, "test_glide_call_duration_seconds"))
+
+	var data metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+	calls := map[string]int64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "glide.sdk.calls" {
+				for _, point := range sum.DataPoints {
+					outcome, _ := point.Attributes.Value("glide.outcome")
+					calls[outcome.AsString()] += point.Value
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"success": 1, "validation": 1}, calls)
+}
+
+func TestFunnelMetrics(t *testing.T) {
+	claims := base64.RawStdEncoding.EncodeToString([]byte(`{"ext":{"operator":"TestOperator"}}`))
+	session := &types.Session{AccessToken: "header." + claims + ".signature"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/sim-swap/retrieve-date" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":404,"code":"SIM_SWAP.UNKNOWN_PHONE_NUMBER","message":"unknown"}`))
+			return
+		}
+		w.Write([]byte(`{"swapped":true}`))
+	}))
+	defer server.Close()
+
+	recorder := &recordingSink{}
+	settings := types.GlideSdkSettings{
+		ClientID:   "client",
+		MetricSink: recorder,
+		Internal:   types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+	userClient := services.NewSimSwapUserClient(settings, types.PhoneIdentifier{PhoneNumber: "+555123456789"})
+	conf := types.ApiConfig{SessionIdentifier: "session", Session: session}
+	_, err := userClient.Check(types.SimSwapCheckParams{}, conf)
+	assert.NoError(t, err)
+	_, err = userClient.RetrieveDate(types.SimSwapRetrieveDateParams{}, conf)
+	assert.Error(t, err)
+	// without a session identifier only the call itself is reported
+	_, err = userClient.Check(types.SimSwapCheckParams{}, types.ApiConfig{Session: session})
+	assert.NoError(t, err)
+
+	var funnel []string
+	for _, m := range recorder.metrics {
+		if m.SessionId == "" {
+			continue
+		}
+		assert.Equal(t, "sim-swap", m.Api)
+		assert.Equal(t, "session", m.SessionId)
+		step := m.MetricName
+		if m.ErrorClass != "" {
+			step += " " + m.ErrorClass
+		}
+		if m.MetricName != "Glide start" {
+			assert.Equal(t, "TestOperator", m.Operator, step)
+		}
+		funnel = append(funnel, step)
+	}
+	assert.Equal(t, []string{
+		"Glide start", "Glide success", "Glide swapped",
+		"Glide start", "Glide failure number_not_supported",
+	}, funnel)
+	assert.Len(t, recorder.metrics, len(funnel)+3)
+}
+
+func TestNumberVerifyFunnelOperator(t *testing.T) {
+	claims := base64.RawStdEncoding.EncodeToString([]byte(`{"ext":{"operator":"TestOperator"}}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/token":
+			w.Write([]byte(`{"access_token":"header.` + claims + `.signature","expires_in":3600,"scope":"openid"}`))
+		case "/number-verification/verify":
+			w.Write([]byte(`{"devicePhoneNumberVerified":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	recorder := &recordingSink{}
+	settings := types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		MetricSink:   recorder,
+		Internal:     types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+	phoneNumber := "+555123456789"
+	userClient, err := services.NewNumberVerifyClient(settings).For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+	assert.NoError(t, err)
+	_, err = userClient.VerifyNumber(nil, types.ApiConfig{SessionIdentifier: "session"})
+	assert.NoError(t, err)
+
+	var funnel []string
+	for _, m := range recorder.metrics {
+		if m.SessionId == "" {
+			continue
+		}
+		// every step, the start included, names the operator from the token
+		assert.Equal(t, "TestOperator", m.Operator, m.MetricName)
+		funnel = append(funnel, m.MetricName)
+	}
+	assert.Equal(t, []string{"Glide numberVerify start function", "Glide success", "Glide verified"}, funnel)
+}
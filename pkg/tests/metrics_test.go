@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// metricServer records the metric payloads it receives, holding each request
+// until release is closed
+type metricServer struct {
+	*httptest.Server
+	release  chan struct{}
+	mu       sync.Mutex
+	payloads []json.RawMessage
+}
+
+func newMetricServer(t *testing.T) *metricServer {
+	s := &metricServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		select {
+		case <-s.release:
+		case <-r.Context().Done():
+			return
+		}
+		s.mu.Lock()
+		s.payloads = append(s.payloads, body)
+		s.mu.Unlock()
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *metricServer) received() []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]json.RawMessage(nil), s.payloads...)
+}
+
+func metric(name string) types.MetricInfo {
+	return types.MetricInfo{SessionId: "session", MetricName: name, Api: "magic-auth", ClientId: "client", Timestamp: time.Now()}
+}
+
+func TestAsyncMetricReporter(t *testing.T) {
+	t.Run("does not block and flushes", func(t *testing.T) {
+		server := newMetricServer(t)
+		reporter := utils.NewAsyncMetricReporter(utils.MetricReporter{URL: server.URL}, types.MetricsPolicy{})
+		start := time.Now()
+		reporter.Report(metric("Glide start"))
+		reporter.Report(metric("Glide success"))
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+		close(server.release)
+		assert.NoError(t, reporter.Flush(context.Background()))
+		assert.Len(t, server.received(), 2)
+		assert.Equal(t, utils.MetricStats{Enqueued: 2, Sent: 2}, reporter.Stats())
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		server := newMetricServer(t)
+		close(server.release)
+		reporter := utils.NewAsyncMetricReporter(utils.MetricReporter{URL: server.URL}, types.MetricsPolicy{BatchSize: 3, FlushInterval: time.Hour})
+		for _, name := range []string{"a", "b", "c", "d"} {
+			reporter.Report(metric(name))
+		}
+		// the partial batch is sent on flush rather than after the interval
+		assert.NoError(t, reporter.Flush(context.Background()))
+		payloads := server.received()
+		assert.Len(t, payloads, 2)
+		var batch []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(payloads[0], &batch))
+		assert.Len(t, batch, 3)
+		var single map[string]interface{}
+		assert.NoError(t, json.Unmarshal(payloads[1], &single))
+		assert.Equal(t, "d", single["metricName"])
+	})
+
+	t.Run("drop policies", func(t *testing.T) {
+		for policy, want := range map[types.MetricDropPolicy]string{types.DropNewest: "b", types.DropOldest: "c"} {
+			server := newMetricServer(t)
+			reporter := utils.NewAsyncMetricReporter(utils.MetricReporter{URL: server.URL}, types.MetricsPolicy{QueueSize: 1, DropPolicy: policy})
+			reporter.Report(metric("a"))
+			// wait for the worker to take "a" so the queue has room for one more
+			assert.Eventually(t, func() bool { return reporter.Stats().Queued == 0 }, time.Second, time.Millisecond)
+			reporter.Report(metric("b"))
+			reporter.Report(metric("c"))
+			assert.Equal(t, uint64(1), reporter.Stats().Dropped)
+
+			close(server.release)
+			assert.NoError(t, reporter.Flush(context.Background()))
+			payloads := server.received()
+			assert.Len(t, payloads, 2)
+			var last map[string]interface{}
+			assert.NoError(t, json.Unmarshal(payloads[1], &last))
+			assert.Equal(t, want, last["metricName"])
+		}
+	})
+
+	t.Run("close gives up at the deadline", func(t *testing.T) {
+		server := newMetricServer(t)
+		reporter := utils.NewAsyncMetricReporter(utils.MetricReporter{URL: server.URL}, types.MetricsPolicy{})
+		reporter.Report(metric("a"))
+		reporter.Report(metric("b"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, reporter.Close(ctx), context.DeadlineExceeded)
+		reporter.Report(metric("c"))
+		stats := reporter.Stats()
+		assert.Equal(t, 0, stats.Queued)
+		assert.Equal(t, uint64(2), stats.Dropped)
+		assert.Equal(t, uint64(0), stats.Sent)
+	})
+
+	t.Run("a hanging metric server does not block close", func(t *testing.T) {
+		server := newMetricServer(t)
+		reporter := utils.NewAsyncMetricReporter(utils.MetricReporter{URL: server.URL, Timeout: 20 * time.Millisecond}, types.MetricsPolicy{})
+		reporter.Report(metric("a"))
+
+		// every attempt times out, so only the retry backoff delays close
+		start := time.Now()
+		assert.NoError(t, reporter.Close(context.Background()))
+		assert.Less(t, time.Since(start), 10*time.Second)
+		assert.Equal(t, uint64(1), reporter.Stats().Failed)
+	})
+}
+
+func TestMetricsDoNotDelayCalls(t *testing.T) {
+	metrics := newMetricServer(t)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified":true}`))
+	}))
+	defer api.Close()
+	t.Setenv("REPORT_METRIC_URL", metrics.URL)
+
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID: "client",
+		Internal: types.InternalSettings{AuthBaseURL: api.URL, APIBaseURL: api.URL},
+	})
+	assert.NoError(t, err)
+
+	start := time.Now()
+	result, err := glideClient.MagicAuth.VerifyAuth(types.MagicAuthVerifyProps{Email: "user@example.com", Code: "123456"}, types.ApiConfig{
+		SessionIdentifier: "session",
+		Session:           &types.Session{AccessToken: "token"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Verified)
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(metrics.release)
+	assert.NoError(t, glideClient.Close(context.Background()))
+	assert.Len(t, metrics.received(), 2)
+	assert.Equal(t, uint64(2), glideClient.MetricStats().Sent)
+}
+
+func TestGlideClientMetricTimeout(t *testing.T) {
+	metrics := newMetricServer(t)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verified":true}`))
+	}))
+	defer api.Close()
+	t.Setenv("REPORT_METRIC_URL", metrics.URL)
+
+	// the metric server never answers, so every send ends at the client's timeout
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID: "client",
+		Timeout:  20 * time.Millisecond,
+		Metrics:  types.MetricsPolicy{BatchSize: 2},
+		Internal: types.InternalSettings{AuthBaseURL: api.URL, APIBaseURL: api.URL},
+	})
+	assert.NoError(t, err)
+	_, err = glideClient.MagicAuth.VerifyAuth(types.MagicAuthVerifyProps{Email: "user@example.com", Code: "123456"}, types.ApiConfig{
+		SessionIdentifier: "session",
+		Session:           &types.Session{AccessToken: "token"},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, glideClient.Close(ctx))
+	assert.Equal(t, uint64(2), glideClient.MetricStats().Failed)
+}
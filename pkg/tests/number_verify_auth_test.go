@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCodeExchangeServer grants every token request, recording the last form
+func newCodeExchangeServer(t *testing.T) (*services.NumberVerifyClient, *url.Values) {
+	form := &url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		*form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","expires_in":3600,"scope":"openid"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := services.NewNumberVerifyClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Internal:     types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	})
+	return client, form
+}
+
+func TestPKCE(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", auth.S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	client, form := newCodeExchangeServer(t)
+	authURL, err := client.GetAuthURL()
+	assert.NoError(t, err)
+	assert.Len(t, authURL.CodeVerifier, 43)
+	parsed, err := url.Parse(authURL.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.S256Challenge(authURL.CodeVerifier), parsed.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+	_, err = client.For(types.NumberVerifyClientForParams{Code: "code", CodeVerifier: authURL.CodeVerifier})
+	assert.NoError(t, err)
+	assert.Equal(t, authURL.CodeVerifier, form.Get("code_verifier"))
+}
+
+func TestNumberVerifyCallback(t *testing.T) {
+	client, form := newCodeExchangeServer(t)
+	phoneNumber := "+555123456789"
+
+	authURL, err := client.GetAuthURL()
+	assert.NoError(t, err)
+	parsed, _ := url.Parse(authURL.URL)
+	assert.Equal(t, authURL.State, parsed.Query().Get("state"))
+	assert.Equal(t, authURL.Nonce, parsed.Query().Get("nonce"))
+	assert.WithinDuration(t, time.Now().Add(auth.DefaultAuthStateTTL), authURL.ExpiresAt, time.Second)
+
+	_, err = client.ForCallback(url.Values{"state": {"forged"}, "code": {"code"}}, &phoneNumber)
+	assert.ErrorIs(t, err, utils.ErrValidation)
+	assert.Empty(t, form.Get("code"))
+
+	callback := url.Values{"state": {authURL.State}, "code": {"code"}}
+	userClient, err := client.ForCallback(callback, &phoneNumber)
+	assert.NoError(t, err)
+	assert.NotNil(t, userClient)
+	assert.Equal(t, "code", form.Get("code"))
+	assert.Equal(t, authURL.CodeVerifier, form.Get("code_verifier"))
+
+	// a replayed callback is rejected
+	_, err = client.ForCallback(callback, &phoneNumber)
+	assert.ErrorIs(t, err, utils.ErrValidation)
+
+	denied, err := client.GetAuthURL()
+	assert.NoError(t, err)
+	_, err = client.ParseCallback(context.Background(), url.Values{
+		"state":             {denied.State},
+		"error":             {"access_denied"},
+		"error_description": {"user cancelled"},
+	})
+	assert.ErrorIs(t, err, utils.ErrAccessDenied)
+	assert.Contains(t, err.Error(), "user cancelled")
+}
+
+func TestAuthStateStores(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]types.AuthStateStore{
+		"memory": auth.NewMemoryAuthStateStore(),
+		"kv":     auth.NewKVAuthStateStore(&mapCache{data: map[string][]byte{}}, "glide:state:"),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, store.Put(ctx, "state", &types.AuthState{Nonce: "nonce", CodeVerifier: "verifier"}, time.Minute))
+			got, err := store.Take(ctx, "state")
+			assert.NoError(t, err)
+			if assert.NotNil(t, got) {
+				assert.Equal(t, "verifier", got.CodeVerifier)
+			}
+			got, err = store.Take(ctx, "state")
+			assert.NoError(t, err)
+			assert.Nil(t, got)
+
+			assert.NoError(t, store.Put(ctx, "expired", &types.AuthState{Nonce: "nonce"}, -time.Second))
+			got, err = store.Take(ctx, "expired")
+			assert.NoError(t, err)
+			assert.Nil(t, got)
+		})
+	}
+}
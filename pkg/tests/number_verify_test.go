@@ -20,12 +20,12 @@ func TestNumberVerify(t *testing.T) {
 	t.Run("should work", func(t *testing.T) {
 		phoneNumber := "+555123456789"
 	    authUrl, err := glideClient.NumberVerify.GetAuthURL(types.NumberVerifyAuthUrlInput{UseDevNumber: phoneNumber})
-		fmt.Println("Open this URL on the user's device: ", authUrl)
 		assert.NoError(t, err)
 		assert.NotNil(t, authUrl)
-		assert.NotEmpty(t, authUrl)
+		fmt.Println("Open this URL on the user's device: ", authUrl.URL)
+		assert.NotEmpty(t, authUrl.URL)
 		t.Logf("authUrl response: %+v", authUrl)
-		baseURL, err := url.Parse(authUrl)
+		baseURL, err := url.Parse(authUrl.URL)
 		if err != nil {
 			t.Fatalf("Failed to parse authUrl: %v", err)
 		}
@@ -42,7 +42,7 @@ func TestNumberVerify(t *testing.T) {
 		t.Logf("Code: %s", code)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, code)
-		client, err := glideClient.NumberVerify.For(types.NumberVerifyClientForParams{PhoneNumber: &phoneNumber, Code: code})
+		client, err := glideClient.NumberVerify.ForCallback(parsedLocation.Query(), &phoneNumber)
 		assert.NoError(t, err)
 		verify, err := client.VerifyNumber(nil, types.ApiConfig{SessionIdentifier: "session77"})
 		assert.NoError(t, err)
@@ -55,8 +55,8 @@ func TestNumberVerify(t *testing.T) {
 	    authUrl, err := glideClient.NumberVerify.GetAuthURL(types.NumberVerifyAuthUrlInput{UseDevNumber: phoneNumber, PrintCode: true})
 		assert.NoError(t, err)
 		assert.NotNil(t, authUrl)
-		assert.NotEmpty(t, authUrl)
-		codeRes, err := http.Get(authUrl)
+		assert.NotEmpty(t, authUrl.URL)
+		codeRes, err := http.Get(authUrl.URL)
 		if err != nil {
 			// handle error
 			t.Errorf("Error making request: %v", err)
@@ -78,7 +78,7 @@ func TestNumberVerify(t *testing.T) {
 		t.Logf("Code: %s", code)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, code)
-		client, err := glideClient.NumberVerify.For(types.NumberVerifyClientForParams{PhoneNumber: &phoneNumber, Code: code})
+		client, err := glideClient.NumberVerify.For(types.NumberVerifyClientForParams{PhoneNumber: &phoneNumber, Code: code, CodeVerifier: authUrl.CodeVerifier})
 		assert.NoError(t, err)
 		verify, err := client.VerifyNumber(nil, types.ApiConfig{SessionIdentifier: "session77"})
 		assert.NoError(t, err)
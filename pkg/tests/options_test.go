@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptions(t *testing.T) {
+	t.Setenv("GLIDE_CLIENT_ID", "env-client")
+	t.Setenv("GLIDE_CLIENT_SECRET", "env-secret")
+	t.Setenv("GLIDE_AUTH_BASE_URL", "https://auth.example.com")
+
+	t.Run("ignores the environment unless asked", func(t *testing.T) {
+		_, err := glide.New()
+		var validationErr *utils.ValidationError
+		if assert.True(t, errors.As(err, &validationErr), "%v", err) {
+			assert.Equal(t, "ClientID", validationErr.Field)
+		}
+
+		client, err := glide.New(glide.WithEnv())
+		assert.NoError(t, err)
+		assert.NotNil(t, client)
+	})
+
+	t.Run("options take precedence over the environment", func(t *testing.T) {
+		// requests reaching the endpoints from the environment are recorded as failures
+		envServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("request to the environment's endpoint %s", r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer envServer.Close()
+		t.Setenv("GLIDE_AUTH_BASE_URL", envServer.URL)
+		t.Setenv("GLIDE_API_BASE_URL", envServer.URL)
+		var credentials []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/oauth2/token" {
+				id, secret, _ := r.BasicAuth()
+				credentials = append(credentials, id+":"+secret)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"token","expires_in":3600,"scope":"telco-finder","networkId":"network"}`))
+		}))
+		defer server.Close()
+
+		client, err := glide.New(
+			glide.WithEnv(),
+			glide.WithCredentials("client", "secret"),
+			glide.WithEndpoints(server.URL, server.URL),
+			glide.WithTimeout(5*time.Second),
+		)
+		assert.NoError(t, err)
+		_, err = client.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"client:secret"}, credentials)
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		_, err := glide.New(
+			glide.WithEndpoints("oidc.example.com", "https://api.example.com"),
+			glide.WithRedirectURI("/callback"),
+			glide.WithTimeout(-time.Second),
+			glide.WithSettings(types.GlideSdkSettings{CIBADeliveryMode: "carrier-pigeon"}),
+		)
+		assert.ErrorIs(t, err, utils.ErrValidation)
+		var fields []string
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			fields = append(fields, e.(*utils.ValidationError).Field)
+		}
+		assert.Equal(t, []string{"ClientID", "Internal.AuthBaseURL", "RedirectURI", "Timeout", "CIBADeliveryMode"}, fields)
+	})
+
+	t.Run("accepts redirect URIs of native apps", func(t *testing.T) {
+		for _, uri := range []string{"https://app.example.com/callback", "com.example.app:/callback", "http://127.0.0.1:8080/callback"} {
+			_, err := glide.New(glide.WithCredentials("client", "secret"), glide.WithRedirectURI(uri))
+			assert.NoError(t, err, uri)
+		}
+	})
+
+	t.Run("checks client authentication without a secret", func(t *testing.T) {
+		err := glide.Validate(types.GlideSdkSettings{
+			ClientID:   "client",
+			ClientAuth: types.ClientAuth{Method: types.PrivateKeyJWT},
+			Internal:   types.InternalSettings{AuthBaseURL: glide.DefaultAuthBaseURL, APIBaseURL: glide.DefaultAPIBaseURL},
+		})
+		var validationErr *utils.ValidationError
+		if assert.True(t, errors.As(err, &validationErr), "%v", err) {
+			assert.Equal(t, "ClientAuth.SigningKey", validationErr.Field)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/phone"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhoneParse(t *testing.T) {
+	valid := []struct {
+		number, region, want string
+	}{
+		{"+555123456789", "", "+555123456789"},
+		{"tel:+34 630 84 46 71", "", "+34630844671"},
+		{"0034 630-84-46-71", "", "+34630844671"},
+		{"0501234567", "IL", "+972501234567"},
+		{"(020) 7946 0018", "GB", "+442079460018"},
+		{"06 12 34 56 78", "FR", "+33612345678"},
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"011 44 20 7946 0018", "US", "+442079460018"},
+	}
+	for _, c := range valid {
+		got, err := phone.Format(c.number, c.region)
+		assert.NoError(t, err, c.number)
+		assert.Equal(t, c.want, got, c.number)
+	}
+
+	parsed, err := phone.Parse("+555123456789", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 55, parsed.CountryCode)
+	assert.Equal(t, "5123456789", parsed.NationalNumber)
+
+	invalid := []struct {
+		number, region string
+	}{
+		{"", ""},
+		{"0501234567", ""},
+		{"+999123456789", ""},
+		{"+5551234567890123", ""},
+		{"+55 51 CALL-NOW", ""},
+		{"+34 63084467", ""},
+		{"0501234567", "XX"},
+	}
+	for _, c := range invalid {
+		_, err := phone.Format(c.number, c.region)
+		assert.Error(t, err, c.number)
+		assert.True(t, errors.Is(err, utils.ErrValidation), c.number)
+	}
+}
+
+func TestInvalidPhoneNumberIsNotSent(t *testing.T) {
+	transport := &recordingTransport{}
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Transport:    transport,
+		Internal: types.InternalSettings{
+			AuthBaseURL: "https://auth.example.invalid",
+			APIBaseURL:  "https://api.example.invalid",
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = glideClient.TelcoFinder.NetworkIdForNumber("0501234567", types.ApiConfig{})
+	var validationErr *utils.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "PhoneNumber", validationErr.Field)
+	_, err = glideClient.TelcoFinder.LookupNumber("+999123456789", types.ApiConfig{})
+	assert.True(t, errors.Is(err, utils.ErrValidation))
+	assert.Empty(t, transport.paths)
+}
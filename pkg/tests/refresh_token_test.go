@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/services"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/ClearBlockchain/sdk-go/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// refreshServer issues a short-lived session for the code, renews it with
+// rotated refresh tokens and records what the API and revocation saw
+type refreshServer struct {
+	mu      sync.Mutex
+	grants  []string
+	bearers []string
+	revoked []string
+	refresh string
+}
+
+func newRefreshServer(t *testing.T, refreshToken string) (*refreshServer, types.GlideSdkSettings) {
+	s := &refreshServer{refresh: refreshToken}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/token":
+			grant := r.PostForm.Get("grant_type")
+			s.grants = append(s.grants, grant)
+			body := map[string]interface{}{"access_token": "code-token", "expires_in": 30, "scope": "openid"}
+			if grant == auth.GrantRefreshToken {
+				if r.PostForm.Get("refresh_token") != s.refresh {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"invalid_grant"}`))
+					return
+				}
+				s.refresh = s.refresh + "-rotated"
+				body["access_token"], body["expires_in"] = "refreshed-token", 3600
+			}
+			if s.refresh != "" {
+				body["refresh_token"] = s.refresh
+			}
+			json.NewEncoder(w).Encode(body)
+		case "/oauth2/revoke":
+			s.revoked = append(s.revoked, r.PostForm.Get("token_type_hint")+":"+r.PostForm.Get("token"))
+		case "/number-verification/verify":
+			s.bearers = append(s.bearers, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			w.Write([]byte(`{"devicePhoneNumberVerified":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return s, types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		SessionStore: auth.NewMemorySessionStore(),
+		Internal:     types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	}
+}
+
+func TestRefreshTokens(t *testing.T) {
+	phoneNumber := "+555123456789"
+
+	t.Run("renews and revokes", func(t *testing.T) {
+		server, settings := newRefreshServer(t, "refresh")
+		client := services.NewNumberVerifyClient(settings)
+		userClient, err := client.For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+		assert.NoError(t, err)
+
+		// the code session is about to expire, so it is renewed before the call
+		for i := 0; i < 2; i++ {
+			result, err := userClient.VerifyNumber(nil, types.ApiConfig{})
+			assert.NoError(t, err)
+			assert.True(t, result.DevicePhoneNumberVerified)
+		}
+		server.mu.Lock()
+		assert.Equal(t, []string{auth.GrantAuthorizationCode, auth.GrantRefreshToken}, server.grants)
+		assert.Equal(t, []string{"refreshed-token", "refreshed-token"}, server.bearers)
+		server.mu.Unlock()
+
+		assert.NoError(t, userClient.Revoke())
+		server.mu.Lock()
+		assert.Equal(t, []string{"refresh_token:refresh-rotated"}, server.revoked)
+		server.mu.Unlock()
+		_, err = userClient.VerifyNumber(nil, types.ApiConfig{})
+		var sessionErr *utils.InsufficientSessionError
+		assert.True(t, errors.As(err, &sessionErr), "%v", err)
+	})
+
+	t.Run("keeps the refresh token in the session store", func(t *testing.T) {
+		_, settings := newRefreshServer(t, "refresh")
+		cache := &mapCache{data: map[string][]byte{}}
+		settings.SessionStore = auth.NewKVSessionStore(cache, "glide:")
+		userClient, err := services.NewNumberVerifyClient(settings).For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+		assert.NoError(t, err)
+		_, err = userClient.VerifyNumber(nil, types.ApiConfig{})
+		assert.NoError(t, err)
+
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if assert.Len(t, cache.data, 1) {
+			for _, data := range cache.data {
+				var stored types.Session
+				assert.NoError(t, json.Unmarshal(data, &stored))
+				assert.Equal(t, "refresh-rotated", stored.RefreshToken)
+				// the session outlives its access token in the store
+				assert.Greater(t, stored.RefreshExpiresAt, stored.ExpiresAt)
+			}
+		}
+	})
+
+	t.Run("resumes a stored session", func(t *testing.T) {
+		server, settings := newRefreshServer(t, "refresh")
+		userClient, err := services.NewNumberVerifyClient(settings).For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+		assert.NoError(t, err)
+		_, err = userClient.VerifyNumber(nil, types.ApiConfig{})
+		assert.NoError(t, err)
+
+		// a new client, as after a restart, continues with the renewed session
+		client := services.NewNumberVerifyClient(settings)
+		resumed, err := client.Resume(userClient.SessionKey(), &phoneNumber)
+		assert.NoError(t, err)
+		_, err = resumed.VerifyNumber(nil, types.ApiConfig{})
+		assert.NoError(t, err)
+		server.mu.Lock()
+		assert.Equal(t, []string{auth.GrantAuthorizationCode, auth.GrantRefreshToken}, server.grants)
+		assert.Equal(t, []string{"refreshed-token", "refreshed-token"}, server.bearers)
+		server.mu.Unlock()
+
+		assert.NoError(t, resumed.Close(context.Background()))
+		_, err = client.Resume(userClient.SessionKey(), &phoneNumber)
+		var sessionErr *utils.InsufficientSessionError
+		assert.True(t, errors.As(err, &sessionErr), "%v", err)
+
+		settings.SessionStore = nil
+		_, err = services.NewNumberVerifyClient(settings).Resume(userClient.SessionKey(), &phoneNumber)
+		assert.ErrorIs(t, err, utils.ErrValidation)
+	})
+
+	t.Run("sessions stay with their client", func(t *testing.T) {
+		_, settings := newRefreshServer(t, "refresh")
+		cache := &mapCache{data: map[string][]byte{}}
+		settings.SessionStore = auth.NewKVSessionStore(cache, "glide:")
+		client := services.NewNumberVerifyClient(settings)
+		first, err := client.For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+		assert.NoError(t, err)
+		second, err := client.For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+		assert.NoError(t, err)
+		cache.mu.Lock()
+		assert.Len(t, cache.data, 2)
+		cache.mu.Unlock()
+
+		// closing one client neither ends the other's session nor leaves its own behind
+		assert.NoError(t, first.Close(context.Background()))
+		_, err = second.VerifyNumber(nil, types.ApiConfig{})
+		assert.NoError(t, err)
+		cache.mu.Lock()
+		assert.Len(t, cache.data, 1)
+		cache.mu.Unlock()
+		assert.NoError(t, second.Close(context.Background()))
+		cache.mu.Lock()
+		assert.Empty(t, cache.data)
+		cache.mu.Unlock()
+	})
+
+	t.Run("without a refresh token the session lasts until it expires", func(t *testing.T) {
+		server, settings := newRefreshServer(t, "")
+		userClient, err := services.NewNumberVerifyClient(settings).For(types.NumberVerifyClientForParams{Code: "code", PhoneNumber: &phoneNumber})
+		assert.NoError(t, err)
+		_, err = userClient.VerifyNumberWithContext(context.Background(), nil, types.ApiConfig{})
+		assert.NoError(t, err)
+		server.mu.Lock()
+		assert.Equal(t, []string{auth.GrantAuthorizationCode}, server.grants)
+		assert.Equal(t, []string{"code-token"}, server.bearers)
+		server.mu.Unlock()
+	})
+}
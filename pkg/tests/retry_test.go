@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var apiCalls, magicCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/token":
+			w.Write([]byte(`{"access_token":"token","expires_in":3600,"scope":"telco-finder magic-auth"}`))
+		case "/telco-finder/v1/resolve-network-id":
+			if atomic.AddInt32(&apiCalls, 1) < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"networkId":"21407"}`))
+		case "/magic-auth/verification/start":
+			atomic.AddInt32(&magicCalls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RetryPolicy:  &types.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		Internal:     types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+
+	t.Run("retries idempotent calls", func(t *testing.T) {
+		response, err := glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, "21407", response.NetworkID)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&apiCalls))
+	})
+
+	t.Run("does not retry calls with side effects", func(t *testing.T) {
+		_, err := glideClient.MagicAuth.StartAuth(types.MagicAuthStartProps{PhoneNumber: "+555123456789"}, types.ApiConfig{})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&magicCalls))
+	})
+}
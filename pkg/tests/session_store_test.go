@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapCache is a KeyValueCache double standing in for Redis
+type mapCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (c *mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *mapCache) Del(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func TestSessionStores(t *testing.T) {
+	ctx := context.Background()
+	fileStore, err := auth.NewFileSessionStore(t.TempDir())
+	assert.NoError(t, err)
+	stores := map[string]types.SessionStore{
+		"memory": auth.NewMemorySessionStore(),
+		"file":   fileStore,
+		"kv":     auth.NewKVSessionStore(&mapCache{data: map[string][]byte{}}, "glide:"),
+	}
+	session := &types.Session{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour).Unix(), Scopes: []string{"sim-swap"}}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			missing, err := store.Get(ctx, "missing")
+			assert.NoError(t, err)
+			assert.Nil(t, missing)
+
+			assert.NoError(t, store.Put(ctx, "key", session, time.Hour))
+			stored, err := store.Get(ctx, "key")
+			assert.NoError(t, err)
+			assert.Equal(t, session, stored)
+
+			assert.NoError(t, store.Delete(ctx, "key"))
+			assert.NoError(t, store.Delete(ctx, "key"))
+			stored, err = store.Get(ctx, "key")
+			assert.NoError(t, err)
+			assert.Nil(t, stored)
+		})
+	}
+
+	t.Run("memory entries expire", func(t *testing.T) {
+		store := auth.NewMemorySessionStore()
+		assert.NoError(t, store.Put(ctx, "key", session, time.Millisecond))
+		time.Sleep(5 * time.Millisecond)
+		stored, err := store.Get(ctx, "key")
+		assert.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("kv keys do not reveal the subscriber", func(t *testing.T) {
+		cache := &mapCache{data: map[string][]byte{}}
+		store := auth.NewKVSessionStore(cache, "glide:")
+		assert.NoError(t, store.Put(ctx, "glide|client|sim-swap|tel:+555123456789", session, time.Hour))
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		for key := range cache.data {
+			assert.Regexp(t, "^glide:[0-9a-f]{64}$", key)
+		}
+	})
+
+	t.Run("token managers share a store", func(t *testing.T) {
+		settings := types.GlideSdkSettings{ClientID: "client", SessionStore: fileStore}
+		key := auth.TokenKey{GrantType: auth.GrantCIBA, Scope: "sim-swap", LoginHint: "tel:+555123456789"}
+		fetches := 0
+		fetch := func(ctx context.Context) (*types.Session, error) {
+			fetches++
+			return session, nil
+		}
+		_, err := auth.NewTokenManager(settings).Get(ctx, key, fetch)
+		assert.NoError(t, err)
+
+		// a fresh manager, as after a restart, finds the stored session
+		restarted := auth.NewTokenManager(settings)
+		assert.Equal(t, session, restarted.Lookup(ctx, key))
+		stored, err := restarted.Get(ctx, key, fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, session, stored)
+		assert.Equal(t, 1, fetches)
+
+		// another client id never sees it
+		other := auth.NewTokenManager(types.GlideSdkSettings{ClientID: "other", SessionStore: fileStore})
+		assert.Nil(t, other.Lookup(ctx, key))
+	})
+}
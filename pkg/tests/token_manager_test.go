@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/auth"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager(t *testing.T) {
+	key := auth.TokenKey{GrantType: auth.GrantClientCredentials, Scope: "telco-finder"}
+
+	t.Run("deduplicates concurrent fetches", func(t *testing.T) {
+		tokens := auth.NewTokenManager(types.GlideSdkSettings{})
+		var fetches int32
+		fetch := func(ctx context.Context) (*types.Session, error) {
+			atomic.AddInt32(&fetches, 1)
+			time.Sleep(20 * time.Millisecond)
+			return &types.Session{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour).Unix()}, nil
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				session, err := tokens.Get(context.Background(), key, fetch)
+				assert.NoError(t, err)
+				assert.Equal(t, "token", session.AccessToken)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		tokens := auth.NewTokenManager(types.GlideSdkSettings{})
+		_, err := tokens.Get(context.Background(), key, func(ctx context.Context) (*types.Session, error) {
+			return nil, errors.New("boom")
+		})
+		assert.Error(t, err)
+		session, err := tokens.Get(context.Background(), key, func(ctx context.Context) (*types.Session, error) {
+			return &types.Session{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour).Unix()}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "token", session.AccessToken)
+	})
+
+	t.Run("serves short-lived sessions from the cache", func(t *testing.T) {
+		tokens := auth.NewTokenManager(types.GlideSdkSettings{})
+		fetches := 0
+		for i := 0; i < 5; i++ {
+			session, err := tokens.Get(context.Background(), key, func(ctx context.Context) (*types.Session, error) {
+				fetches++
+				return &types.Session{AccessToken: "token", ExpiresAt: time.Now().Add(time.Minute).Unix()}, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "token", session.AccessToken)
+		}
+		assert.Equal(t, 1, fetches)
+	})
+
+	t.Run("drops expired sessions", func(t *testing.T) {
+		tokens := auth.NewTokenManager(types.GlideSdkSettings{})
+		ctx := context.Background()
+		subscriber := func(i int) auth.TokenKey {
+			return auth.TokenKey{GrantType: auth.GrantCIBA, Scope: "sim-swap", LoginHint: fmt.Sprintf("tel:+5551234%05d", i)}
+		}
+		expiresAt := time.Now().Add(-time.Second).Unix()
+		for i := 0; i < 100; i++ {
+			_, err := tokens.Get(ctx, subscriber(i), func(ctx context.Context) (*types.Session, error) {
+				return &types.Session{AccessToken: "token", ExpiresAt: expiresAt}, nil
+			})
+			assert.NoError(t, err)
+		}
+		live, err := tokens.Get(ctx, key, func(ctx context.Context) (*types.Session, error) {
+			return &types.Session{AccessToken: "live", ExpiresAt: time.Now().Add(time.Hour).Unix()}, nil
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, tokens.Latest(ctx, subscriber(0)))
+		assert.Equal(t, live, tokens.Latest(ctx, key))
+	})
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	claims := base64.RawStdEncoding.EncodeToString([]byte(`{"ext":{"operator":"TestOperator"}}`))
+	accessToken := "header." + claims + ".signature"
+	var mu sync.Mutex
+	traceparents := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents[r.URL.Path] = r.Header.Get("traceparent")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/backchannel-authentication":
+			w.Write([]byte(`{"auth_req_id":"req"}`))
+		case "/oauth2/token":
+			w.Write([]byte(`{"access_token":"` + accessToken + `","expires_in":3600,"scope":"sim-swap"}`))
+		default:
+			w.Write([]byte(`{"swapped":false}`))
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		Internal:       types.InternalSettings{AuthBaseURL: server.URL, APIBaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	userClient, err := glideClient.SimSwap.For(types.PhoneIdentifier{PhoneNumber: "+555123456789"})
+	assert.NoError(t, err)
+	_, err = userClient.Check(types.SimSwapCheckParams{}, types.ApiConfig{})
+	assert.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		name := span.Name()
+		if name == "HTTP POST" {
+			name += " " + attributeValue(span, "url.path")
+		}
+		spans[name] = span
+	}
+	check := spans["sim-swap.check"]
+	if !assert.NotNil(t, check) {
+		return
+	}
+	assert.Equal(t, "success", attributeValue(check, "glide.outcome"))
+	assert.Equal(t, "TestOperator", attributeValue(check, "glide.operator"))
+
+	session := spans["auth.session"]
+	token := spans["HTTP POST /oauth2/token"]
+	api := spans["HTTP POST /sim-swap/check"]
+	assert.Equal(t, check.SpanContext().SpanID(), session.Parent().SpanID())
+	assert.Equal(t, session.SpanContext().SpanID(), token.Parent().SpanID())
+	assert.Equal(t, check.SpanContext().SpanID(), api.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, api.SpanKind())
+	assert.Equal(t, "sim-swap.start-session", spans["sim-swap.start-session"].Name())
+
+	// the gateway sees the HTTP span as the parent of its own
+	traceID := check.SpanContext().TraceID().String()
+	assert.Equal(t, "00-"+traceID+"-"+api.SpanContext().SpanID().String()+"-01", traceparents["/sim-swap/check"])
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/glide"
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTransport answers every request locally and remembers the paths it saw
+type recordingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.paths = append(rt.paths, req.URL.Path)
+	rt.mu.Unlock()
+	body := `{"networkId":"21407"}`
+	if req.URL.Path == "/oauth2/token" {
+		body = `{"access_token":"token","expires_in":3600,"scope":"telco-finder"}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestCustomTransport(t *testing.T) {
+	transport := &recordingTransport{}
+	glideClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Transport:    transport,
+		Internal: types.InternalSettings{
+			AuthBaseURL: "https://auth.example.invalid",
+			APIBaseURL:  "https://api.example.invalid",
+		},
+	})
+	assert.NoError(t, err)
+
+	response, err := glideClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "21407", response.NetworkID)
+	assert.Equal(t, []string{"/.well-known/openid-configuration", "/oauth2/token", "/telco-finder/v1/resolve-network-id"}, transport.paths)
+
+	// another client of the same gateway discovers it through its own transport
+	other := &recordingTransport{}
+	otherClient, err := glide.NewGlideClient(types.GlideSdkSettings{
+		ClientID:     "other",
+		ClientSecret: "secret",
+		Transport:    other,
+		Internal: types.InternalSettings{
+			AuthBaseURL: "https://auth.example.invalid",
+			APIBaseURL:  "https://api.example.invalid",
+		},
+	})
+	assert.NoError(t, err)
+	_, err = otherClient.TelcoFinder.NetworkIdForNumber("+555123456789", types.ApiConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/.well-known/openid-configuration", "/oauth2/token", "/telco-finder/v1/resolve-network-id"}, other.paths)
+	assert.Len(t, transport.paths, 3)
+}
@@ -1,16 +1,144 @@
 package types
 
-import "time"
+import (
+    "context"
+    "crypto"
+    "crypto/tls"
+    "log/slog"
+    "net/http"
+    "time"
+
+    "go.opentelemetry.io/otel/trace"
+)
 
 // GlideSdkSettings represents the settings for the Glide SDK
 type GlideSdkSettings struct {
     ClientID     string
     ClientSecret string
+    // ClientAuth selects how the client authenticates to the authorization
+    // server; the zero value uses ClientSecret
+    ClientAuth   ClientAuth
     RedirectURI  string
+    // UseEnv fills the fields left unset from the GLIDE_* environment variables
+    // when the client is created, see glide.WithEnv
     UseEnv       bool
+    // Timeout bounds every service call that does not set ApiConfig.Timeout;
+    // zero falls back to utils.DefaultTimeout
+    Timeout      time.Duration
+    // HTTPClient is used for every auth, API and metric request; it takes
+    // precedence over Transport
+    HTTPClient   *http.Client
+    // Transport is wrapped in an http.Client when HTTPClient is not set, e.g. to
+    // route through a proxy or trust custom TLS roots
+    Transport    http.RoundTripper
+    // SessionStore persists sessions beyond the in-memory cache, e.g. to share
+    // tokens across a fleet or keep CIBA sessions over a restart
+    SessionStore SessionStore
+    // AuthStateStore keeps the state, nonce and PKCE verifier of number
+    // verification auth URLs until their callback; nil keeps them in memory,
+    // which only works when the callback reaches the same process
+    AuthStateStore AuthStateStore
+    // RetryPolicy controls retries of idempotent requests; nil uses utils.DefaultRetryPolicy
+    RetryPolicy  *RetryPolicy
+    // Logger receives structured debug and warning events; nil keeps the SDK silent
+    Logger       *slog.Logger
+    // Redaction controls how subscriber data and credentials are masked in logs and errors
+    Redaction    RedactionPolicy
+    // DefaultRegion is the ISO 3166 country, e.g. "GB", assumed for phone numbers
+    // given in national format; without it only international numbers are accepted
+    DefaultRegion string
+    // MetricSink receives usage metrics; nil posts them to REPORT_METRIC_URL
+    // through a background queue tuned by Metrics
+    MetricSink   MetricSink
+    // Metrics tunes the background queue metrics are reported through
+    Metrics      MetricsPolicy
+    // TracerProvider enables spans for service calls, token acquisition and HTTP
+    // requests, with W3C trace context sent to the gateway; nil disables tracing
+    TracerProvider trace.TracerProvider
+    // CIBADeliveryMode is how SimSwap learns that the user approved; ping and
+    // push need SimSwapClient.NotificationHandler served at the client
+    // notification endpoint registered with Glide
+    CIBADeliveryMode CIBADeliveryMode
     Internal     InternalSettings
 }
 
+// ClientAuthMethod is how the client authenticates to the token and backchannel
+// authentication endpoints
+type ClientAuthMethod string
+
+const (
+    // ClientSecretBasic sends ClientID and ClientSecret with HTTP Basic; the default
+    ClientSecretBasic ClientAuthMethod = "client_secret_basic"
+    // PrivateKeyJWT sends a client assertion signed with ClientAuth.SigningKey (RFC 7523)
+    PrivateKeyJWT ClientAuthMethod = "private_key_jwt"
+    // TLSClientAuth presents ClientAuth.Certificate in the TLS handshake (RFC 8705)
+    TLSClientAuth ClientAuthMethod = "tls_client_auth"
+)
+
+// ClientAuth configures client authentication without a shared secret
+type ClientAuth struct {
+    // Method defaults to ClientSecretBasic
+    Method      ClientAuthMethod
+    // SigningKey signs private_key_jwt assertions; an RSA key signs with RS256,
+    // an EC key with ES256, ES384 or ES512 depending on its curve
+    SigningKey  crypto.Signer
+    // KeyID is the kid of the assertions, naming the key registered with Glide
+    KeyID       string
+    // Certificate is presented for tls_client_auth. It is added to the
+    // Transport, or the default one; an HTTPClient must be set up with it instead.
+    Certificate *tls.Certificate
+}
+
+// MetricDropPolicy decides which metric is discarded when the report queue is full
+type MetricDropPolicy int
+
+const (
+    // DropNewest discards the metric being reported
+    DropNewest MetricDropPolicy = iota
+    // DropOldest discards the longest queued metric to make room
+    DropOldest
+)
+
+// MetricsPolicy tunes the background metric reporter. The zero value queues up
+// to 1024 metrics and sends each on its own.
+type MetricsPolicy struct {
+    // QueueSize bounds the metrics waiting to be sent
+    QueueSize     int
+    // BatchSize metrics are posted together as a JSON array; leave it at 1 unless
+    // the metric server accepts arrays
+    BatchSize     int
+    // FlushInterval is how long a partial batch waits for more metrics
+    FlushInterval time.Duration
+    // DropPolicy applies when the queue is full
+    DropPolicy    MetricDropPolicy
+}
+
+// RedactionPolicy controls how the SDK masks phone numbers, emails, IP addresses,
+// tokens and secrets. The zero value masks everything.
+type RedactionPolicy struct {
+    // Disabled turns redaction off, e.g. for local debugging
+    Disabled           bool
+    // VisiblePhoneDigits trailing digits of phone numbers stay readable, e.g. 3
+    // turns +555123456789 into +*********789
+    VisiblePhoneDigits int
+    // KeepEmailDomain leaves the domain of email addresses readable
+    KeepEmailDomain    bool
+    // KeepIPs leaves IP addresses readable
+    KeepIPs            bool
+}
+
+// RetryPolicy controls how transient request failures are retried
+type RetryPolicy struct {
+    // MaxAttempts counts the first attempt too, so 1 disables retries
+    MaxAttempts       int
+    // BaseDelay is the backoff before the second attempt, doubled for every further one
+    BaseDelay         time.Duration
+    // MaxDelay caps both the backoff and a server requested Retry-After
+    MaxDelay          time.Duration
+    // RetryableStatuses lists the HTTP statuses worth retrying; nil uses 429, 502, 503 and 504
+    RetryableStatuses []int
+}
+
 // InternalSettings represents internal settings for the SDK
 type InternalSettings struct {
     AuthBaseURL string
@@ -19,15 +147,77 @@ type InternalSettings struct {
 
 // Session represents an authentication session
 type Session struct {
-    AccessToken string
-    ExpiresAt   int64
-    Scopes      []string
+    AccessToken string   `json:"accessToken"`
+    ExpiresAt   int64    `json:"expiresAt"`
+    Scopes      []string `json:"scopes"`
+    // RefreshToken renews the session after ExpiresAt, until RefreshExpiresAt
+    RefreshToken     string `json:"refreshToken,omitempty"`
+    RefreshExpiresAt int64  `json:"refreshExpiresAt,omitempty"`
+}
+
+// SessionStore persists sessions under opaque keys. Implementations must be safe
+// for concurrent use; see the auth package for the ones shipped with the SDK.
+type SessionStore interface {
+    // Get returns the session stored under key, or nil if there is none or its TTL passed
+    Get(ctx context.Context, key string) (*Session, error)
+    // Put stores session under key for ttl
+    Put(ctx context.Context, key string, session *Session, ttl time.Duration) error
+    // Delete removes key; deleting a missing key is not an error
+    Delete(ctx context.Context, key string) error
+}
+
+// IDTokenClaims are the claims of a verified OpenID Connect id_token
+type IDTokenClaims struct {
+    Issuer          string
+    Subject         string
+    Audience        []string
+    AuthorizedParty string
+    Nonce           string
+    ExpiresAt       time.Time
+    IssuedAt        time.Time
+    // Raw holds every claim, including operator specific ones
+    Raw             map[string]interface{}
+}
+
+// TokenIntrospection is what the authorization server knows about a token. An
+// inactive token, expired, revoked or unknown, only has Active set.
+type TokenIntrospection struct {
+    Active    bool   `json:"active"`
+    Scope     string `json:"scope"`
+    ClientID  string `json:"client_id"`
+    Subject   string `json:"sub"`
+    TokenType string `json:"token_type"`
+    Issuer    string `json:"iss"`
+    ExpiresAt int64  `json:"exp"`
+    IssuedAt  int64  `json:"iat"`
+    // Raw holds every member of the response, including aud and extensions
+    Raw       map[string]interface{} `json:"-"`
+}
+
+// AuthState is what a number verification callback is checked against
+type AuthState struct {
+    Nonce        string    `json:"nonce"`
+    CodeVerifier string    `json:"codeVerifier"`
+    ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// AuthStateStore keeps pending authorization requests by their state parameter.
+// Implementations must be safe for concurrent use.
+type AuthStateStore interface {
+    // Put stores data under state for ttl
+    Put(ctx context.Context, state string, data *AuthState, ttl time.Duration) error
+    // Take removes and returns the data stored under state, or nil if there is
+    // none or its TTL passed, so every state is accepted once
+    Take(ctx context.Context, state string) (*AuthState, error)
 }
 
 // ApiConfig represents the configuration for API calls
 type ApiConfig struct {
 	SessionIdentifier string
     Session *Session
+    // Timeout bounds this call, including session acquisition; it overrides
+    // GlideSdkSettings.Timeout
+    Timeout time.Duration
 }
 
 // TelcoFinderNetworkIdResponse represents the response for network ID lookup
@@ -102,6 +292,17 @@ type NumberVerifyAuthUrlInput struct {
 }
 
 
+// NumberVerifyAuthURL is an authorization URL to open on the user's device
+// together with the values its callback is checked against
+type NumberVerifyAuthURL struct {
+    URL          string
+    State        string
+    Nonce        string
+    CodeVerifier string
+    // ExpiresAt is when the state is forgotten and the callback rejected
+    ExpiresAt    time.Time
+}
+
 type NumberVerifyResponse struct {
 	DevicePhoneNumberVerified bool
 }
@@ -110,6 +311,10 @@ type NumberVerifyResponse struct {
 type NumberVerifyClientForParams struct {
     Code        string
     PhoneNumber *string
+    // CodeVerifier is the PKCE verifier returned with the auth URL the code was issued for
+    CodeVerifier string
+    // Nonce is the nonce of that auth URL; the id_token must carry it
+    Nonce        string
 }
 
 //sim swap
@@ -122,6 +327,32 @@ type SimSwapRetrieveDateParams struct {
 	PhoneNumber string
 }
 
+// CIBADeliveryMode selects how the result of a CIBA request reaches the client
+type CIBADeliveryMode string
+
+const (
+    // CIBAPoll polls the token endpoint until the user answers; the default
+    CIBAPoll CIBADeliveryMode = "poll"
+    // CIBAPing has the operator call back once the token is ready to be fetched
+    CIBAPing CIBADeliveryMode = "ping"
+    // CIBAPush has the operator call back with the token itself
+    CIBAPush CIBADeliveryMode = "push"
+)
+
+// CIBAPollProgress describes a CIBA poll the user has not answered yet
+type CIBAPollProgress struct {
+    // Attempt counts the polls made so far
+    Attempt    int
+    // Status is the token endpoint error, "authorization_pending" or "slow_down"
+    Status     string
+    // Interval is the wait before the next poll
+    Interval   time.Duration
+    // ExpiresAt is when the authentication request expires, zero if unknown
+    ExpiresAt  time.Time
+    // ConsentURL is where the user approves the request, if the operator asks for explicit consent
+    ConsentURL string
+}
+
 // Implement the UserIdentifier interface for each identifier type
 func (PhoneIdentifier) isUserIdentifier()  {}
 func (IpIdentifier) isUserIdentifier()     {}
@@ -135,6 +366,19 @@ type MetricInfo struct {
 	MetricName  string    `json:"metricName"`
 	Api         string    `json:"api"`
 	ClientId    string    `json:"clientId"`
+	// Outcome is set on the metric reported when a call completes, named after
+	// the operation, e.g. "sim-swap.check": "success" or a utils.ErrorClass
+	Outcome     string        `json:"outcome,omitempty"`
+	// Latency is how long the completed call took
+	Latency     time.Duration `json:"latency,omitempty"`
+	// ErrorClass is set on the "Glide failure" funnel metric, see utils.ErrorClass
+	ErrorClass  string        `json:"errorClass,omitempty"`
+}
+
+// MetricSink receives the metrics of the SDK. Report is called concurrently on
+// the request path and must not block.
+type MetricSink interface {
+    Report(metric MetricInfo)
 }
 
 type TokenData struct {
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Failure classes of SDK errors; match them with errors.Is
+var (
+	ErrInvalidCredentials  = errors.New("invalid client credentials")
+	ErrInsufficientScope   = errors.New("insufficient scope")
+	ErrNumberNotSupported  = errors.New("number not supported by operator")
+	ErrConsentRequired     = errors.New("consent required")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrValidation          = errors.New("validation failed")
+	ErrAccessDenied        = errors.New("access denied by the user")
+	ErrAuthExpired         = errors.New("authentication request expired")
+	ErrInvalidToken        = errors.New("invalid token")
+)
+
+// GlideError is the error returned by service methods. It matches its Kind with
+// errors.Is and exposes its cause, typically a *FetchError, to errors.As.
+type GlideError struct {
+	// Kind is one of the Err* failure classes, or nil if the failure is unclassified
+	Kind error
+	// Op names the failing operation, e.g. "sim-swap.check"
+	Op      string
+	Message string
+	Err     error
+	// Redactor masks subscriber data in Error(); nil uses the one of the wrapped
+	// FetchError, if any, and DefaultRedactor otherwise
+	Redactor *Redactor
+}
+
+func (e *GlideError) Error() string {
+	msg := "[GlideClient] " + e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return e.redactor().String(msg)
+}
+
+func (e *GlideError) redactor() *Redactor {
+	if e.Redactor != nil {
+		return e.Redactor
+	}
+	var fetchErr *FetchError
+	if errors.As(e.Err, &fetchErr) {
+		return fetchErr.Redactor
+	}
+	return nil
+}
+
+func (e *GlideError) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// ValidationError reports invalid input or configuration before any request is sent
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "[GlideClient] " + e.Message
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// NewError creates a GlideError of the given kind
+func NewError(op string, kind error, message string, err error) *GlideError {
+	return &GlideError{Kind: kind, Op: op, Message: message, Err: err}
+}
+
+// WrapError wraps err in a GlideError classified by Classify. Errors that
+// already are a GlideError or ValidationError are returned unchanged.
+func WrapError(op, message string, err error) error {
+	var glideErr *GlideError
+	var validationErr *ValidationError
+	if errors.As(err, &glideErr) || errors.As(err, &validationErr) {
+		return err
+	}
+	return NewError(op, Classify(err), message, err)
+}
+
+// Classify maps err to one of the Err* failure classes, or nil if it does not fit one
+func Classify(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		// the request never got an HTTP response
+		return ErrUpstreamUnavailable
+	}
+	if kind := classifyCode(fetchErr.Code()); kind != nil {
+		return kind
+	}
+	switch status := fetchErr.Response.StatusCode; {
+	case status == http.StatusBadRequest:
+		// OAuth token endpoint errors carry the reason in the body
+		return ClassifyOAuthError(fetchErr.OAuthError())
+	case status == http.StatusUnauthorized:
+		return ErrInvalidCredentials
+	case status == http.StatusForbidden:
+		return ErrInsufficientScope
+	case status == http.StatusNotFound, status == http.StatusUnprocessableEntity:
+		return ErrNumberNotSupported
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500:
+		return ErrUpstreamUnavailable
+	}
+	return nil
+}
+
+// ClassifyOAuthError maps an OAuth error code, e.g. from a token response or an
+// authorization callback, to one of the Err* failure classes
+func ClassifyOAuthError(code string) error {
+	switch code {
+	case "invalid_client", "unauthorized_client":
+		return ErrInvalidCredentials
+	case "invalid_scope":
+		return ErrInsufficientScope
+	case "authorization_pending", "slow_down":
+		return ErrConsentRequired
+	case "access_denied":
+		return ErrAccessDenied
+	case "expired_token":
+		return ErrAuthExpired
+	}
+	return ErrValidation
+}
+
+// ErrorClass names the failure class of err for metric and trace attributes:
+// "invalid_credentials", "insufficient_scope", "number_not_supported",
+// "consent_required", "access_denied", "auth_expired", "invalid_token",
+// "rate_limited", "upstream_unavailable", "validation", "canceled", "timeout" or
+// "error" if it fits none. A nil err is "success".
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	for _, class := range errorClasses {
+		if errors.Is(err, class.kind) {
+			return class.name
+		}
+	}
+	return "error"
+}
+
+var errorClasses = []struct {
+	kind error
+	name string
+}{
+	{ErrValidation, "validation"},
+	{ErrInvalidCredentials, "invalid_credentials"},
+	{ErrInsufficientScope, "insufficient_scope"},
+	{ErrNumberNotSupported, "number_not_supported"},
+	{ErrConsentRequired, "consent_required"},
+	{ErrAccessDenied, "access_denied"},
+	{ErrAuthExpired, "auth_expired"},
+	{ErrInvalidToken, "invalid_token"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrUpstreamUnavailable, "upstream_unavailable"},
+}
+
+// classifyCode maps CAMARA error codes, which are more specific than the HTTP
+// status, to a failure class. API specific codes are prefixed with the API name,
+// e.g. "SIM_SWAP.UNKNOWN_PHONE_NUMBER".
+func classifyCode(code string) error {
+	if code == "" {
+		return nil
+	}
+	if i := strings.LastIndex(code, "."); i >= 0 {
+		code = code[i+1:]
+	}
+	switch code {
+	case "UNAUTHENTICATED", "INVALID_CLIENT":
+		return ErrInvalidCredentials
+	case "PERMISSION_DENIED", "INVALID_TOKEN_CONTEXT":
+		return ErrInsufficientScope
+	case "UNKNOWN_PHONE_NUMBER", "NOT_FOUND", "IDENTIFIER_NOT_FOUND", "UNSUPPORTED_IDENTIFIER", "SERVICE_NOT_APPLICABLE", "NOT_SUPPORTED":
+		return ErrNumberNotSupported
+	case "TOO_MANY_REQUESTS", "QUOTA_EXCEEDED":
+		return ErrRateLimited
+	case "INVALID_ARGUMENT", "OUT_OF_RANGE", "MISSING_IDENTIFIER", "IDENTIFIER_MISMATCH", "UNNECESSARY_IDENTIFIER":
+		return ErrValidation
+	case "UNAVAILABLE", "INTERNAL", "TIMEOUT":
+		return ErrUpstreamUnavailable
+	}
+	return nil
+}
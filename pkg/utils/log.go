@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+// discardLogger drops every record; the SDK is silent unless given a logger
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Logger returns the logger configured in settings behind the redaction policy
+// in settings, or one that discards everything
+func Logger(settings types.GlideSdkSettings) *slog.Logger {
+	if settings.Logger == nil {
+		return discardLogger
+	}
+	return slog.New(RedactorFor(settings).Handler(settings.Logger.Handler()))
+}
+
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+	return logger
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+const (
+	defaultMetricQueueSize     = 1024
+	defaultMetricFlushInterval = time.Second
+)
+
+// MetricStats counts what happened to the metrics given to an AsyncMetricReporter
+type MetricStats struct {
+	// Queued metrics are waiting to be sent
+	Queued int
+	// Enqueued counts every metric accepted into the queue
+	Enqueued uint64
+	// Sent counts metrics the metric server accepted
+	Sent uint64
+	// Dropped counts metrics discarded because the queue was full or the reporter closed
+	Dropped uint64
+	// Failed counts metrics that could not be sent after retries
+	Failed uint64
+}
+
+// AsyncMetricReporter is the default MetricSink. It posts metrics to the metric
+// server from a background goroutine so reporting never blocks the caller.
+// Metrics wait in a bounded queue and are sent in batches; the goroutine only
+// runs while there is something to send.
+type AsyncMetricReporter struct {
+	reporter MetricReporter
+	policy   types.MetricsPolicy
+
+	// ctx aborts in-flight sends once Close gives up waiting
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	queue    []types.MetricInfo
+	closed   bool
+	flushing int
+	idle     chan struct{} // closed when the running worker exits, nil if none runs
+	wake     chan struct{}
+
+	enqueued atomic.Uint64
+	sent     atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+}
+
+// NewAsyncMetricReporter creates a reporter that sends through reporter, tuned by policy
+func NewAsyncMetricReporter(reporter MetricReporter, policy types.MetricsPolicy) *AsyncMetricReporter {
+	if policy.QueueSize <= 0 {
+		policy.QueueSize = defaultMetricQueueSize
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = 1
+	}
+	if policy.BatchSize > policy.QueueSize {
+		policy.BatchSize = policy.QueueSize
+	}
+	if policy.FlushInterval <= 0 {
+		policy.FlushInterval = defaultMetricFlushInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AsyncMetricReporter{
+		reporter: reporter,
+		policy:   policy,
+		ctx:      ctx,
+		cancel:   cancel,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Report queues report and returns at once. When the queue is full the drop
+// policy decides which metric is lost; after Close every report is dropped.
+// Metrics without a session, such as call completions, are not for the metric
+// server and are ignored.
+func (r *AsyncMetricReporter) Report(report types.MetricInfo) {
+	if report.SessionId == "" {
+		return
+	}
+	logger := orDiscard(r.reporter.Logger)
+	if r.reporter.url() == "" {
+		logger.Debug("metric not reported, REPORT_METRIC_URL is unset", "metricName", report.MetricName)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		r.dropped.Add(1)
+		logger.Debug("metric dropped, reporter is closed", "metricName", report.MetricName)
+		return
+	}
+	if len(r.queue) >= r.policy.QueueSize {
+		r.dropped.Add(1)
+		if r.policy.DropPolicy != types.DropOldest {
+			logger.Debug("metric dropped, queue is full", "metricName", report.MetricName)
+			return
+		}
+		logger.Debug("oldest metric dropped, queue is full", "metricName", r.queue[0].MetricName)
+		r.queue = r.queue[1:]
+	}
+	r.queue = append(r.queue, report)
+	r.enqueued.Add(1)
+	if len(r.queue) >= r.policy.BatchSize {
+		r.signal()
+	}
+	if r.idle == nil {
+		r.idle = make(chan struct{})
+		go r.run(r.idle)
+	}
+}
+
+// Flush waits until every queued metric has been sent or given up on. Partial
+// batches are sent without waiting for FlushInterval.
+func (r *AsyncMetricReporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	idle := r.idle
+	if idle == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	r.flushing++
+	r.signal()
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.flushing--
+		r.mu.Unlock()
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting metrics and flushes the queue. If ctx is done first
+// the metrics still queued are dropped, in-flight sends are aborted and ctx's
+// error is returned.
+func (r *AsyncMetricReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	err := r.Flush(ctx)
+	if err != nil {
+		r.mu.Lock()
+		r.dropped.Add(uint64(len(r.queue)))
+		r.queue = nil
+		r.mu.Unlock()
+	}
+	r.cancel()
+	return err
+}
+
+// Stats reports the queue length and what happened to the metrics so far
+func (r *AsyncMetricReporter) Stats() MetricStats {
+	r.mu.Lock()
+	queued := len(r.queue)
+	r.mu.Unlock()
+	return MetricStats{
+		Queued:   queued,
+		Enqueued: r.enqueued.Load(),
+		Sent:     r.sent.Load(),
+		Dropped:  r.dropped.Load(),
+		Failed:   r.failed.Load(),
+	}
+}
+
+// signal wakes a worker waiting for its batch to fill; r.mu must be held
+func (r *AsyncMetricReporter) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends batches until the queue is empty, then exits and closes idle
+func (r *AsyncMetricReporter) run(idle chan struct{}) {
+	defer close(idle)
+	for {
+		batch := r.next()
+		if batch == nil {
+			return
+		}
+		r.send(batch)
+	}
+}
+
+// next takes the next batch off the queue, waiting up to FlushInterval for a
+// partial one to fill. It returns nil and retires the worker once the queue is empty.
+func (r *AsyncMetricReporter) next() []types.MetricInfo {
+	r.mu.Lock()
+	if len(r.queue) > 0 && len(r.queue) < r.policy.BatchSize && !r.closed && r.flushing == 0 {
+		r.mu.Unlock()
+		timer := time.NewTimer(r.policy.FlushInterval)
+		select {
+		case <-r.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+		r.mu.Lock()
+	}
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		r.idle = nil
+		return nil
+	}
+	n := min(len(r.queue), r.policy.BatchSize)
+	batch := r.queue[:n:n]
+	r.queue = r.queue[n:]
+	return batch
+}
+
+func (r *AsyncMetricReporter) send(batch []types.MetricInfo) {
+	logger := orDiscard(r.reporter.Logger)
+	var payload interface{} = metricPayload(batch[0])
+	if len(batch) > 1 {
+		payloads := make([]map[string]interface{}, len(batch))
+		for i, report := range batch {
+			payloads[i] = metricPayload(report)
+		}
+		payload = payloads
+	}
+	if err := r.reporter.send(r.ctx, r.reporter.url(), payload); err != nil {
+		r.failed.Add(uint64(len(batch)))
+		logger.Error("failed to report metrics after multiple attempts", "metrics", len(batch), "error", err)
+		return
+	}
+	r.sent.Add(uint64(len(batch)))
+	logger.Debug("metrics reported", "metrics", len(batch))
+}
+
+// MetricSinkFor returns the sink of settings, or else a new AsyncMetricReporter
+// sending through the HTTP client, logger and timeout of settings
+func MetricSinkFor(settings types.GlideSdkSettings) types.MetricSink {
+	if settings.MetricSink != nil {
+		return settings.MetricSink
+	}
+	reporter := MetricReporter{Client: HTTPClient(settings), Logger: Logger(settings), Timeout: settings.Timeout}
+	return NewAsyncMetricReporter(reporter, settings.Metrics)
+}
+
+// MultiMetricSink reports every metric to each of sinks
+func MultiMetricSink(sinks ...types.MetricSink) types.MetricSink {
+	return multiMetricSink(sinks)
+}
+
+type multiMetricSink []types.MetricSink
+
+func (m multiMetricSink) Report(metric types.MetricInfo) {
+	for _, sink := range m {
+		sink.Report(metric)
+	}
+}
+
+// Close closes the sinks that can be closed, see CloseMetricSink
+func (m multiMetricSink) Close(ctx context.Context) error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, CloseMetricSink(ctx, sink))
+	}
+	return errors.Join(errs...)
+}
+
+// CloseMetricSink flushes and closes sink if it has a Close(context.Context) error
+// method, like AsyncMetricReporter
+func CloseMetricSink(ctx context.Context, sink types.MetricSink) error {
+	if closer, ok := sink.(interface{ Close(context.Context) error }); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	authHeaderPattern = regexp.MustCompile(`(?i)\b(Bearer|Basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	ipv4Pattern       = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// full or "::" compressed addresses only, so clock times are left alone
+	ipv6Pattern = regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b(?:[0-9a-f]{1,4}:){1,7}:(?:[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*)?`)
+	// phone numbers in international format, or bare runs long enough to be an MSISDN
+	phonePattern = regexp.MustCompile(`\+\d[\d\s().-]{5,18}\d|\b\d{9,15}\b`)
+)
+
+// Redactor masks subscriber data and credentials in text. A nil Redactor
+// behaves like DefaultRedactor.
+type Redactor struct {
+	policy  types.RedactionPolicy
+	secrets []string
+}
+
+// DefaultRedactor masks everything the SDK knows how to recognize
+var DefaultRedactor = NewRedactor(types.RedactionPolicy{})
+
+// NewRedactor creates a Redactor applying policy that additionally masks the
+// given secrets wherever they appear
+func NewRedactor(policy types.RedactionPolicy, secrets ...string) *Redactor {
+	r := &Redactor{policy: policy}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// RedactorFor applies the policy in settings and masks its client secret
+func RedactorFor(settings types.GlideSdkSettings) *Redactor {
+	return NewRedactor(settings.Redaction, settings.ClientSecret)
+}
+
+// String masks credentials, tokens, emails, IP addresses and phone numbers in s
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		r = DefaultRedactor
+	}
+	if r.policy.Disabled || s == "" {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	s = authHeaderPattern.ReplaceAllString(s, "$1 "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		if r.policy.KeepEmailDomain {
+			return "***@" + email[strings.LastIndex(email, "@")+1:]
+		}
+		return "***@***"
+	})
+	if !r.policy.KeepIPs {
+		// before phone numbers, the digits of an address would otherwise look like one
+		s = ipv4Pattern.ReplaceAllString(s, "***.***.***.***")
+		s = ipv6Pattern.ReplaceAllString(s, "***:***")
+	}
+	return phonePattern.ReplaceAllStringFunc(s, r.Phone)
+}
+
+// Phone masks all digits of phone but the trailing ones the policy keeps visible
+func (r *Redactor) Phone(phone string) string {
+	if r == nil {
+		r = DefaultRedactor
+	}
+	if r.policy.Disabled {
+		return phone
+	}
+	visible := r.policy.VisiblePhoneDigits
+	digits := 0
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	var b strings.Builder
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			digits--
+			if digits >= visible {
+				c = '*'
+			}
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Handler wraps h so every string, error and message it receives is redacted
+func (r *Redactor) Handler(h slog.Handler) slog.Handler {
+	if r != nil && r.policy.Disabled {
+		return h
+	}
+	return &redactingHandler{next: h, redactor: r}
+}
+
+type redactingHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	clean := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		clean.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		clean[i] = h.attr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(clean), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *redactingHandler) attr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.String(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		clean := make([]any, len(group))
+		for i, member := range group {
+			clean[i] = h.attr(member)
+		}
+		return slog.Group(attr.Key, clean...)
+	case slog.KindAny:
+		// errors and arbitrary values are flattened to text so nothing escapes unmasked
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, h.redactor.String(err.Error()))
+		}
+		return slog.String(attr.Key, h.redactor.String(fmt.Sprint(value.Any())))
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
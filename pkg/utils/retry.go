@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+)
+
+// DefaultRetryPolicy is used when neither the settings nor the request set one
+var DefaultRetryPolicy = types.RetryPolicy{
+	MaxAttempts:       3,
+	BaseDelay:         200 * time.Millisecond,
+	MaxDelay:          5 * time.Second,
+	RetryableStatuses: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+func resolveRetryPolicy(policy *types.RetryPolicy) types.RetryPolicy {
+	if policy == nil {
+		return DefaultRetryPolicy
+	}
+	resolved := *policy
+	if resolved.BaseDelay <= 0 {
+		resolved.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if resolved.MaxDelay <= 0 {
+		resolved.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if resolved.RetryableStatuses == nil {
+		resolved.RetryableStatuses = DefaultRetryPolicy.RetryableStatuses
+	}
+	return resolved
+}
+
+// canRetry reports whether repeating the request cannot cause a second side effect
+func canRetry(input FetchXInput) bool {
+	switch input.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, "":
+		return true
+	}
+	return input.Idempotent
+}
+
+// isRetryable reports whether err is a transient failure worth another attempt
+func isRetryable(policy types.RetryPolicy, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var fetchErr *FetchError
+	if errors.As(err, &fetchErr) {
+		return slices.Contains(policy.RetryableStatuses, fetchErr.Response.StatusCode)
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryDelay honors a Retry-After header on err, otherwise backs off exponentially
+// from BaseDelay with full jitter, never exceeding MaxDelay
+func retryDelay(policy types.RetryPolicy, attempt int, err error) time.Duration {
+	var fetchErr *FetchError
+	if errors.As(err, &fetchErr) {
+		if delay, ok := parseRetryAfter(fetchErr.Response.Header.Get("Retry-After")); ok {
+			return min(delay, policy.MaxDelay)
+		}
+	}
+	backoff := policy.BaseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > policy.MaxDelay {
+		backoff = policy.MaxDelay
+	}
+	return rand.N(backoff) + 1
+}
+
+// parseRetryAfter accepts both the delay-seconds and HTTP-date forms
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning early with ctx's error if it is done first
+// or its deadline would pass before d elapses
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
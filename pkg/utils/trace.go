@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName identifies the SDK to OpenTelemetry
+const InstrumentationName = "github.com/ClearBlockchain/sdk-go"
+
+// Tracer returns the tracer of the TracerProvider in settings, or nil when
+// tracing is off
+func Tracer(settings types.GlideSdkSettings) trace.Tracer {
+	if settings.TracerProvider == nil {
+		return nil
+	}
+	return settings.TracerProvider.Tracer(InstrumentationName)
+}
+
+// StartSpan starts a span with tracer; a nil tracer returns ctx and a span that
+// records nothing
+func StartSpan(ctx context.Context, tracer trace.Tracer, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return tracer.Start(ctx, name, opts...)
+}
+
+// EndSpan marks span failed if err is not nil and ends it. Only the class of
+// err is recorded, its message may carry subscriber data.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		class := ErrorClass(err)
+		span.SetAttributes(attribute.String("error.type", class))
+		span.SetStatus(codes.Error, class)
+	}
+	span.End()
+}
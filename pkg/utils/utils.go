@@ -2,18 +2,26 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ClearBlockchain/sdk-go/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HTTPResponseError represents an HTTP error response
@@ -39,7 +47,8 @@ func (e *InsufficientSessionError) Error() string {
     return "Session is required for this request"
 }
 
-// FormatPhoneNumber formats a phone number string
+// FormatPhoneNumber strips everything but digits and prefixes "+". It does not
+// validate the number, use the phone package for that.
 func FormatPhoneNumber(phoneNumber string) string {
     re := regexp.MustCompile("[^0-9]")
     return "+" + re.ReplaceAllString(phoneNumber, "")
@@ -49,10 +58,144 @@ func FormatPhoneNumber(phoneNumber string) string {
 type FetchError struct {
     Response *http.Response
     Data     string
+    // Problem is the decoded error body, nil when the body is not a CAMARA error
+    Problem  *ProblemDetails
+    // CorrelationID is the id the gateway tagged the failed request with, if any
+    CorrelationID string
+    // Redactor masks subscriber data the gateway echoed back in Error(); nil uses DefaultRedactor
+    Redactor *Redactor
+}
+
+// ProblemDetails is the error body returned by CAMARA APIs
+type ProblemDetails struct {
+    Status  int    `json:"status"`
+    Code    string `json:"code"`
+    Message string `json:"message"`
+}
+
+// correlationHeaders are checked in order for the id of a failed request
+var correlationHeaders = []string{"X-Correlator", "X-Correlation-Id", "X-Request-Id"}
+
+func newFetchError(resp *http.Response, data []byte, redactor *Redactor) *FetchError {
+    fetchErr := &FetchError{Response: resp, Data: string(data), Redactor: redactor}
+    var problem ProblemDetails
+    if err := json.Unmarshal(data, &problem); err == nil && (problem.Code != "" || problem.Message != "") {
+        if problem.Status == 0 {
+            problem.Status = resp.StatusCode
+        }
+        fetchErr.Problem = &problem
+    }
+    for _, header := range correlationHeaders {
+        if id := resp.Header.Get(header); id != "" {
+            fetchErr.CorrelationID = id
+            break
+        }
+    }
+    return fetchErr
 }
 
 func (e *FetchError) Error() string {
-    return fmt.Sprintf("Fetch Error: %d %s", e.Response.StatusCode, e.Response.Status)
+    msg := fmt.Sprintf("Fetch Error: %d %s", e.Response.StatusCode, e.Response.Status)
+    if e.Problem != nil {
+        msg += fmt.Sprintf(" (%s: %s)", e.Problem.Code, e.Redactor.String(e.Problem.Message))
+    }
+    return msg
+}
+
+// Status is the HTTP status of the failed request
+func (e *FetchError) Status() int {
+    return e.Response.StatusCode
+}
+
+// Code is the CAMARA error code, e.g. "SIM_SWAP.UNKNOWN_PHONE_NUMBER", or "" if the body had none
+func (e *FetchError) Code() string {
+    if e.Problem == nil {
+        return ""
+    }
+    return e.Problem.Code
+}
+
+// Message is the human readable CAMARA error message, or "" if the body had none
+func (e *FetchError) Message() string {
+    if e.Problem == nil {
+        return ""
+    }
+    return e.Problem.Message
+}
+
+// OAuthError is the error code of an OAuth error response, e.g.
+// "authorization_pending", or "" if the body is not one
+func (e *FetchError) OAuthError() string {
+    var body struct {
+        Error string `json:"error"`
+    }
+    if json.Unmarshal([]byte(e.Data), &body) != nil {
+        return ""
+    }
+    return body.Error
+}
+
+// AsFetchError finds the FetchError in err's chain, giving access to the status,
+// CAMARA code and correlation id of any error returned by a service method
+func AsFetchError(err error) (*FetchError, bool) {
+    var fetchErr *FetchError
+    ok := errors.As(err, &fetchErr)
+    return fetchErr, ok
+}
+
+// DefaultTimeout is applied to service calls when neither the settings nor the
+// ApiConfig specify a timeout
+const DefaultTimeout = 30 * time.Second
+
+// defaultHTTPClient is shared by all requests so connections are reused; deadlines
+// come from the request context rather than a client-wide timeout
+var defaultHTTPClient = &http.Client{}
+
+// certClients holds the clients presenting a tls_client_auth certificate, keyed
+// by certificate and base transport, so their connections are reused
+var certClients sync.Map
+
+type certClientKey struct {
+    certificate *tls.Certificate
+    transport   http.RoundTripper
+}
+
+// HTTPClient returns the client configured in settings, wrapping a custom
+// Transport if that is all that was given, or the shared default client. The
+// client certificate of settings, if any, is added to the transport.
+func HTTPClient(settings types.GlideSdkSettings) *http.Client {
+    if settings.HTTPClient != nil {
+        return settings.HTTPClient
+    }
+    if settings.ClientAuth.Certificate != nil {
+        return certClient(settings.ClientAuth.Certificate, settings.Transport)
+    }
+    if settings.Transport != nil {
+        return &http.Client{Transport: settings.Transport}
+    }
+    return defaultHTTPClient
+}
+
+func certClient(certificate *tls.Certificate, transport http.RoundTripper) *http.Client {
+    key := certClientKey{certificate, transport}
+    if client, ok := certClients.Load(key); ok {
+        return client.(*http.Client)
+    }
+    base, ok := transport.(*http.Transport)
+    if !ok {
+        // only an *http.Transport can present the certificate, others are used as they are
+        if transport != nil {
+            return &http.Client{Transport: transport}
+        }
+        base = http.DefaultTransport.(*http.Transport)
+    }
+    withCert := base.Clone()
+    if withCert.TLSClientConfig == nil {
+        withCert.TLSClientConfig = &tls.Config{}
+    }
+    withCert.TLSClientConfig.Certificates = []tls.Certificate{*certificate}
+    client, _ := certClients.LoadOrStore(key, &http.Client{Transport: withCert})
+    return client.(*http.Client)
 }
 
 // FetchXInput represents input for FetchX function
@@ -60,6 +203,21 @@ type FetchXInput struct {
     Method  string
     Headers map[string]string
     Body    string
+    // Timeout bounds this single request on top of any deadline already on the context
+    Timeout time.Duration
+    // Client sends the request; nil uses the shared default client
+    Client  *http.Client
+    // Retry overrides DefaultRetryPolicy
+    Retry   *types.RetryPolicy
+    // Idempotent marks a POST as safe to retry; other methods are judged by their HTTP semantics
+    Idempotent bool
+    // Logger receives request events; nil discards them
+    Logger  *slog.Logger
+    // Redactor masks subscriber data in the returned FetchError; nil uses DefaultRedactor
+    Redactor *Redactor
+    // Tracer records a client span per attempt and propagates its trace context
+    // in W3C headers; nil disables both
+    Tracer  trace.Tracer
 }
 
 // FetchXResponse represents the response from FetchX function
@@ -82,9 +240,75 @@ func (r *FetchXResponse) OK() bool {
 
 // FetchX performs an HTTP request
 func FetchX(url string, input FetchXInput) (*FetchXResponse, error) {
-    client := &http.Client{}
+    return FetchXWithContext(context.Background(), url, input)
+}
+
+// FetchXWithContext performs an HTTP request bound to ctx, so cancelling ctx or
+// reaching its deadline aborts the call, including reading the response body.
+// Failed attempts are retried according to input.Retry if the request is safe to repeat.
+func FetchXWithContext(ctx context.Context, url string, input FetchXInput) (*FetchXResponse, error) {
+    if input.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, input.Timeout)
+        defer cancel()
+    }
+
+    logger := orDiscard(input.Logger)
+    policy := resolveRetryPolicy(input.Retry)
+    for attempt := 1; ; attempt++ {
+        start := time.Now()
+        res, err := tracedFetch(ctx, url, attempt, input)
+        logRequest(ctx, logger, input.Method, url, attempt, time.Since(start), res, err)
+        if err == nil || attempt >= policy.MaxAttempts || !canRetry(input) || !isRetryable(policy, err) {
+            return res, err
+        }
+        delay := retryDelay(policy, attempt, err)
+        logger.WarnContext(ctx, "retrying request", "method", input.Method, "url", url, "attempt", attempt, "delay", delay, "error", err)
+        if waitErr := sleepContext(ctx, delay); waitErr != nil {
+            // report the upstream failure rather than our own give-up
+            return nil, err
+        }
+    }
+}
+
+// logRequest records the outcome of one attempt. Only the method, URL and status
+// are logged, never headers or bodies, which carry tokens and subscriber data.
+func logRequest(ctx context.Context, logger *slog.Logger, method, url string, attempt int, elapsed time.Duration, res *FetchXResponse, err error) {
+    if err == nil {
+        logger.DebugContext(ctx, "http request", "method", method, "url", url, "attempt", attempt, "status", res.Response.StatusCode, "duration", elapsed)
+        return
+    }
+    if fetchErr, ok := AsFetchError(err); ok {
+        logger.DebugContext(ctx, "http request failed", "method", method, "url", url, "attempt", attempt, "status", fetchErr.Status(),
+            "code", fetchErr.Code(), "correlationId", fetchErr.CorrelationID, "duration", elapsed)
+        return
+    }
+    logger.DebugContext(ctx, "http request failed", "method", method, "url", url, "attempt", attempt, "error", err, "duration", elapsed)
+}
+
+// tracedFetch runs one attempt in a client span
+func tracedFetch(ctx context.Context, rawURL string, attempt int, input FetchXInput) (*FetchXResponse, error) {
+    attrs := []attribute.KeyValue{attribute.String("http.request.method", input.Method)}
+    if u, err := neturl.Parse(rawURL); err == nil {
+        // the query is left out, it may carry subscriber data
+        attrs = append(attrs, attribute.String("server.address", u.Hostname()), attribute.String("url.path", u.Path))
+    }
+    if attempt > 1 {
+        attrs = append(attrs, attribute.Int("http.request.resend_count", attempt-1))
+    }
+    ctx, span := StartSpan(ctx, input.Tracer, "HTTP "+input.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+    res, err := fetchOnce(ctx, rawURL, input)
+    if res != nil {
+        span.SetAttributes(attribute.Int("http.response.status_code", res.Response.StatusCode))
+    } else if fetchErr, ok := AsFetchError(err); ok {
+        span.SetAttributes(attribute.Int("http.response.status_code", fetchErr.Status()))
+    }
+    EndSpan(span, err)
+    return res, err
+}
 
-    req, err := http.NewRequest(input.Method, url, strings.NewReader(input.Body))
+func fetchOnce(ctx context.Context, url string, input FetchXInput) (*FetchXResponse, error) {
+    req, err := http.NewRequestWithContext(ctx, input.Method, url, strings.NewReader(input.Body))
     if err != nil {
         return nil, err
     }
@@ -92,6 +316,14 @@ func FetchX(url string, input FetchXInput) (*FetchXResponse, error) {
     for k, v := range input.Headers {
         req.Header.Set(k, v)
     }
+    if input.Tracer != nil {
+        propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+    }
+
+    client := input.Client
+    if client == nil {
+        client = defaultHTTPClient
+    }
 
     resp, err := client.Do(req)
     if err != nil {
@@ -105,13 +337,24 @@ func FetchX(url string, input FetchXInput) (*FetchXResponse, error) {
     }
 
     if resp.StatusCode >= 400 {
-        return nil, &FetchError{Response: resp, Data: string(data)}
+        return nil, newFetchError(resp, data, input.Redactor)
     }
 
     return &FetchXResponse{Data: data, Response: resp}, nil
 }
 
+// WithTimeout derives a context bounded by timeout. A zero timeout leaves ctx
+// untouched; the returned cancel func must always be called.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+    if timeout <= 0 {
+        return context.WithCancel(ctx)
+    }
+    return context.WithTimeout(ctx, timeout)
+}
 
+// GetOperator reads the operator from the access token payload. The signature is
+// not checked, so use it for reporting only; verified identity comes from the
+// id_token, see auth.IDTokenVerifier.
 func GetOperator(session *types.Session) (string, error) {
 	if session == nil {
 		return "", errors.New("[GlideClient] Session is required to get operator")
@@ -132,57 +375,117 @@ func GetOperator(session *types.Session) (string, error) {
 }
 
 
+// MetricReporter posts metrics to the metric server
+type MetricReporter struct {
+	// Client sends the reports; nil uses the shared default client
+	Client *http.Client
+	// URL overrides the REPORT_METRIC_URL environment variable
+	URL string
+	// Logger receives reporting events; nil discards them
+	Logger *slog.Logger
+	// Timeout bounds each attempt to send a report; zero uses DefaultMetricTimeout
+	Timeout time.Duration
+}
+
+// DefaultMetricTimeout bounds an attempt to send a report when MetricReporter.Timeout is unset
+const DefaultMetricTimeout = 10 * time.Second
+
+// ReportMetric reports a metric with the default client to REPORT_METRIC_URL
 func ReportMetric(report types.MetricInfo) {
-	reportToServer := map[string]interface{}{
-		"sessionId":  report.SessionId,
-		"metricName": report.MetricName,
-		"timestamp":  report.Timestamp.Format(time.RFC3339), // ISO 8601 format
-		"api":        report.Api,
-		"clientId":   report.ClientId,
-		"operator":   report.Operator,
-	}
-	url := os.Getenv("REPORT_METRIC_URL")
+	MetricReporter{}.Report(report)
+}
+
+// Report sends report to the metric server, retrying with exponential backoff
+func (r MetricReporter) Report(report types.MetricInfo) {
+	url := r.url()
+	logger := orDiscard(r.Logger)
 	if url == "" {
-		fmt.Println("missing process env REPORT_METRIC_URL")
+		logger.Debug("metric not reported, REPORT_METRIC_URL is unset", "metricName", report.MetricName)
+		return
+	}
+	if err := r.send(context.Background(), url, metricPayload(report)); err != nil {
+		logger.Error("failed to report metric after multiple attempts", "metricName", report.MetricName, "api", report.Api)
 		return
 	}
+	logger.Debug("metric reported", "metricName", report.MetricName, "api", report.Api)
+}
+
+func (r MetricReporter) url() string {
+	if r.URL != "" {
+		return r.URL
+	}
+	return os.Getenv("REPORT_METRIC_URL")
+}
+
+// send posts payload to url, retrying with exponential backoff until ctx is done
+func (r MetricReporter) send(ctx context.Context, url string, payload interface{}) error {
+	client := r.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	logger := orDiscard(r.Logger)
 	const maxRetries = 3
-	attempt := 0
 	retryDelay := func(attempt int) time.Duration {
 		return time.Duration(1<<attempt) * time.Second // Exponential backoff: 1s, 2s, 4s
 	}
-	for attempt < maxRetries {
-		err := sendMetric(url, reportToServer)
-		if err == nil {
-			return // Successfully sent the metric
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := sleepContext(ctx, retryDelay(attempt)); sleepErr != nil {
+				return err
+			}
 		}
-		fmt.Printf("Error reporting to metric server (attempt %d): %v\n", attempt+1, err)
-		attempt++
-		if attempt < maxRetries {
-			time.Sleep(retryDelay(attempt))
+		if err = r.attempt(ctx, client, url, payload); err == nil {
+			return nil
 		}
+		logger.Warn("error reporting to metric server", "attempt", attempt+1, "error", err)
+	}
+	return err
+}
+
+// attempt sends payload once, giving up after r.Timeout so a hanging metric
+// server cannot hold up a flush
+func (r MetricReporter) attempt(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultMetricTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sendMetric(ctx, client, url, payload)
+}
+
+// metricPayload is the wire format of report
+func metricPayload(report types.MetricInfo) map[string]interface{} {
+	payload := map[string]interface{}{
+		"sessionId":  report.SessionId,
+		"metricName": report.MetricName,
+		"timestamp":  report.Timestamp.Format(time.RFC3339), // ISO 8601 format
+		"api":        report.Api,
+		"clientId":   report.ClientId,
+		"operator":   report.Operator,
+	}
+	if report.ErrorClass != "" {
+		payload["errorClass"] = report.ErrorClass
 	}
-	fmt.Println("Failed to report metric after multiple attempts")
+	return payload
 }
 
-func sendMetric(url string, data map[string]interface{}) error {
-	fmt.Println("Sending metric to: ", url)
+func sendMetric(ctx context.Context, client *http.Client, url string, data interface{}) error {
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal report data: %w", err)
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
-	fmt.Println("Response status: ", resp.Status)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {